@@ -0,0 +1,258 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A ParseError is an error in a field of a cron expression, or in what comes
+// before them, such as a time zone prefix. Parse wraps it with the
+// expression, so that it must be retrieved with errors.As, e.g.:
+//
+//	var perr *cron.ParseError
+//	if errors.As(err, &perr) {
+//		highlight(expr[perr.Offset : perr.Offset+perr.Len])
+//	}
+type ParseError struct {
+	Field Field
+	Code  ErrorCode
+
+	// Offset and Len locate the offending token in the expression given to
+	// Parse, in bytes. The token is empty at the end of the expression if a
+	// field is missing, and spans the whole expression if the dialect
+	// rewrites it before parsing its fields, as DialectSystemd does, or if
+	// it expands to a macro.
+	Offset, Len int
+
+	// Min and Max are the bounds that the value of the token violates, if
+	// Code is CodeOutOfRange.
+	Min, Max int
+
+	// Err describes the error.
+	Err error
+
+	// Suggestion is a hint on how to fix the error, if any, e.g., `did you
+	// mean "mon"?` for "mn" in the days of week field.
+	Suggestion string
+}
+
+func (e *ParseError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("field %q: %v (%s)", e.Field, e.Err, e.Suggestion)
+	}
+	return fmt.Sprintf("field %q: %v", e.Field, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// shiftError moves the token of err, if it is a *ParseError, n bytes forward,
+// for callers that pass the part of their input starting at n to the function
+// that returned err.
+func shiftError(err error, n int) error {
+	if perr, ok := err.(*ParseError); ok {
+		perr.Offset += n
+	}
+	return err
+}
+
+// An ErrorCode identifies the kind of a ParseError.
+type ErrorCode int
+
+const (
+	// CodeSyntax is a malformed token, e.g., "5x", "1-" or "L-".
+	CodeSyntax ErrorCode = iota
+
+	// CodeEmpty is an empty or missing field.
+	CodeEmpty
+
+	// CodeOutOfRange is a number out of the bounds of its field, or of its
+	// part of the field, e.g., "60" in the minutes field, or "6" in "1#6".
+	CodeOutOfRange
+
+	// CodeTooManyFields is a field after the last one accepted.
+	CodeTooManyFields
+
+	// CodeNotSupported is a part of the syntax not accepted with the given
+	// options, e.g., "L" without FeatureModifiers, or "H" without WithHash.
+	CodeNotSupported
+
+	// CodeModifierInList is the L, W or # modifier listed with other days,
+	// which the Quartz syntax rejects.
+	CodeModifierInList
+
+	// CodeImpossibleDate is a days of month field that matches no day of
+	// the months field, e.g., "30" in February.
+	CodeImpossibleDate
+
+	// CodeReversedRange is a range that ends before it starts where ranges
+	// cannot wrap around, e.g., "H(30-10)" or "30~10".
+	CodeReversedRange
+)
+
+// String returns the name of the code.
+func (c ErrorCode) String() string {
+	switch c {
+	case CodeSyntax:
+		return "syntax"
+	case CodeEmpty:
+		return "empty"
+	case CodeOutOfRange:
+		return "out of range"
+	case CodeTooManyFields:
+		return "too many fields"
+	case CodeNotSupported:
+		return "not supported"
+	case CodeModifierInList:
+		return "modifier in list"
+	case CodeImpossibleDate:
+		return "impossible date"
+	case CodeReversedRange:
+		return "reversed range"
+	default:
+		return strconv.FormatInt(int64(c), 10)
+	}
+}
+
+// ParseErrors is the error wrapped by the error of Parse with WithAllErrors,
+// which lists every invalid field of an expression, in order. errors.As finds
+// the first of them as a *ParseError, and the list itself as a ParseErrors.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, perr := range e {
+		msgs[i] = perr.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// As sets target to the first error of e if it is a **ParseError.
+func (e ParseErrors) As(target interface{}) bool {
+	if p, ok := target.(**ParseError); ok && len(e) > 0 {
+		*p = e[0]
+		return true
+	}
+	return false
+}
+
+// Unwrap returns the errors of e.
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, perr := range e {
+		errs[i] = perr
+	}
+	return errs
+}
+
+// suggest sets the suggestion of e, unless already set, from its token in
+// expr, whose fields start at base and number fields, and the options of
+// Parse.
+func (e *ParseError) suggest(expr string, base, fields int, o *options) {
+	token := expr[e.Offset : e.Offset+e.Len]
+	name, nameFound := "", false
+	if e.Code == CodeSyntax && (e.Field == FieldMonths || e.Field == FieldDaysOfWeek) {
+		name, nameFound = nearestName(e.Field, token, o.quartz || o.kubernetes)
+	}
+	switch {
+	case e.Suggestion != "":
+	case strings.Contains(token, "?") && !o.quartz && !o.kubernetes:
+		e.Suggestion = `"?" is Quartz syntax; use "*", or parse with WithDialect(DialectQuartz)`
+	case e.Code == CodeOutOfRange && e.Min == 1 && e.Offset > base && expr[e.Offset-1] == '/' && strings.Trim(token, "0") == "":
+		start := base + strings.LastIndexAny(expr[base:e.Offset-1], " ,") + 1
+		e.Suggestion = fmt.Sprintf("steps start at 1; did you mean %q?", expr[start:e.Offset-1])
+	case nameFound:
+		e.Suggestion = fmt.Sprintf("did you mean %q?", name)
+
+	// Fields shifted by a missing or extra seconds field are likely out of
+	// range, unless the sixth field is a years field, as it is in
+	// EventBridge.
+	case fields == 6 && !o.seconds && !o.eventBridge && !looksLikeYears(expr, base):
+		e.Suggestion = "six fields found; for a leading seconds field, parse with WithSeconds"
+	case fields == 5 && o.seconds:
+		e.Suggestion = "five fields found, but a leading seconds field expected"
+	}
+}
+
+// looksLikeYears reports whether the sixth field of expr, whose fields start
+// at base, has a number of four digits or more, as years do.
+func looksLikeYears(expr string, base int) bool {
+	if base < 0 {
+		return false
+	}
+	fields := strings.Split(expr[base:], " ")
+	if len(fields) < 6 {
+		return false
+	}
+	digits := 0
+	for _, c := range fields[5] {
+		if c < '0' || c > '9' {
+			digits = 0
+		} else if digits++; digits >= 4 {
+			return true
+		}
+	}
+	return false
+}
+
+// nearestName returns the name of a month or day of week closest to s, if
+// any is close enough to be a likely misspelling of s. If abbrOnly is set,
+// the name is always a three-letter abbreviation, e.g., "thu" for "thursday".
+func nearestName(typ Field, s string, abbrOnly bool) (name string, ok bool) {
+	names := monNames[:]
+	if typ == FieldDaysOfWeek {
+		names = dowNames[:]
+	}
+	s = strings.ToLower(s)
+	best := 2
+	if len(s) <= 3 {
+		best = 1
+	}
+	for _, full := range names {
+		for _, n := range [...]string{full[:3], full} {
+			if d := editDistance(s, n); d <= best && (!ok || d < best) {
+				name, ok, best = n, true, d
+			}
+		}
+		if ok && abbrOnly && name == full {
+			name = full[:3]
+		}
+	}
+	return name, ok
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// transpositions of adjacent bytes needed to turn a into b.
+func editDistance(a, b string) int {
+	// d[i][j] is the distance between a[:i] and b[:j].
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, minInt(d[i][j-1]+1, d[i-1][j-1]+cost))
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,169 @@
+package cron_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"fmrsn.com/cron"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		expr  string
+		opts  []cron.Option
+		field cron.Field
+		code  cron.ErrorCode
+		token string
+		off   int
+	}{
+		{"60 * * * *", nil, cron.FieldMinutes, cron.CodeOutOfRange, "60", 0},
+		{"0 24 * * *", nil, cron.FieldHours, cron.CodeOutOfRange, "24", 2},
+		{"0 1-24 * * *", nil, cron.FieldHours, cron.CodeOutOfRange, "24", 4},
+		{"0 1,2,3x * * *", nil, cron.FieldHours, cron.CodeSyntax, "3x", 6},
+		{"*/0 * * * *", nil, cron.FieldMinutes, cron.CodeOutOfRange, "0", 2},
+		{"0 0 1-5/40 * *", nil, cron.FieldDaysOfMonth, cron.CodeOutOfRange, "40", 8},
+		{"0 0 1, * *", nil, cron.FieldDaysOfMonth, cron.CodeSyntax, ",", 5},
+		{"0 0 1- * *", nil, cron.FieldDaysOfMonth, cron.CodeSyntax, "-", 5},
+		{"0 0 1/ * *", nil, cron.FieldDaysOfMonth, cron.CodeSyntax, "/", 5},
+		{"0 0 L-31 * *", nil, cron.FieldDaysOfMonth, cron.CodeOutOfRange, "31", 6},
+		{"0 0 Lx * *", nil, cron.FieldDaysOfMonth, cron.CodeSyntax, "x", 5},
+		{"0 0 * foo *", nil, cron.FieldMonths, cron.CodeSyntax, "foo", 6},
+		{"0 0 * * 7#1,mon#6", nil, cron.FieldDaysOfWeek, cron.CodeOutOfRange, "6", 16},
+		{"0 0 * * 1L", []cron.Option{cron.WithDialect(cron.DialectVixie)}, cron.FieldDaysOfWeek, cron.CodeNotSupported, "1L", 8},
+		{"0 0 * * * 2200", nil, cron.FieldYears, cron.CodeOutOfRange, "2200", 10},
+		{"0 0 * * * *", []cron.Option{cron.WithDialect(cron.DialectPOSIX)}, cron.FieldYears, cron.CodeTooManyFields, "*", 10},
+		{"0 0 *", nil, cron.FieldMonths, cron.CodeEmpty, "", 5},
+		{"0 0 30 2 *", nil, cron.FieldDaysOfMonth, cron.CodeImpossibleDate, "30", 4},
+		{"H(0-60) * * * *", []cron.Option{cron.WithHash("key")}, cron.FieldMinutes, cron.CodeOutOfRange, "60", 4},
+		{"H * * * *", nil, cron.FieldMinutes, cron.CodeNotSupported, "H", 0},
+		{"10~70 * * * *", nil, cron.FieldMinutes, cron.CodeNotSupported, "~", 2},
+		{"TZ=UTC 0 0 32 * *", nil, cron.FieldDaysOfMonth, cron.CodeOutOfRange, "32", 11},
+		{"0 60 0 * * *", []cron.Option{cron.WithSeconds()}, cron.FieldMinutes, cron.CodeOutOfRange, "60", 2},
+		{"0 0 0 ? * 2,3L", []cron.Option{cron.WithDialect(cron.DialectQuartz)}, cron.FieldDaysOfWeek, cron.CodeModifierInList, "2,3L", 10},
+		{"cron(0 25 * * ? *)", []cron.Option{cron.WithDialect(cron.DialectEventBridge)}, cron.FieldHours, cron.CodeOutOfRange, "25", 7},
+		{"cron(0 0 * * ?)", []cron.Option{cron.WithDialect(cron.DialectEventBridge)}, cron.FieldYears, cron.CodeEmpty, "", 14},
+		{"*-*-32", []cron.Option{cron.WithDialect(cron.DialectSystemd)}, cron.FieldDaysOfMonth, cron.CodeOutOfRange, "*-*-32", 0},
+		{"0 0 12 * * MON", []cron.Option{cron.WithDialect(cron.DialectQuartz)}, cron.FieldDaysOfMonth, cron.CodeSyntax, "*", 7},
+		{"0 0 12 ? * ?", []cron.Option{cron.WithDialect(cron.DialectQuartz)}, cron.FieldDaysOfWeek, cron.CodeSyntax, "?", 11},
+		{"cron(0 10 1 * MON *)", []cron.Option{cron.WithDialect(cron.DialectEventBridge)}, cron.FieldDaysOfWeek, cron.CodeSyntax, "MON", 14},
+		{"TZ=UTC 0 0 * * *", []cron.Option{cron.WithDialect(cron.DialectPOSIX)}, cron.FieldPrefix, cron.CodeNotSupported, "TZ=UTC", 0},
+		{"CRON_TZ= 0 0 * * *", nil, cron.FieldPrefix, cron.CodeEmpty, "CRON_TZ=", 0},
+		{"@daily", []cron.Option{cron.WithDialect(cron.DialectPOSIX)}, cron.FieldPrefix, cron.CodeNotSupported, "@daily", 0},
+		{"TZ=UTC @fortnightly", nil, cron.FieldPrefix, cron.CodeSyntax, "@fortnightly", 7},
+	}
+	for _, tt := range tests {
+		_, err := cron.Parse(tt.expr, tt.opts...)
+		var perr *cron.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: expected a *ParseError, got %v", tt.expr, err)
+			continue
+		}
+		if perr.Field != tt.field || perr.Code != tt.code {
+			t.Errorf("%q: wrong error\ngot:  %v, %v\nwant: %v, %v", tt.expr, perr.Field, perr.Code, tt.field, tt.code)
+		}
+		if perr.Offset != tt.off || tt.expr[perr.Offset:perr.Offset+perr.Len] != tt.token {
+			t.Errorf("%q: wrong token\ngot:  %q at %d\nwant: %q at %d",
+				tt.expr, tt.expr[perr.Offset:perr.Offset+perr.Len], perr.Offset, tt.token, tt.off)
+		}
+	}
+
+	_, err := cron.Parse("0 0 * 13 *")
+	var perr *cron.ParseError
+	if !errors.As(err, &perr) || perr.Min != 1 || perr.Max != 12 {
+		t.Errorf("expected bounds [1, 12], got %v", err)
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		opts   []cron.Option
+		fields []cron.Field
+		codes  []cron.ErrorCode
+	}{
+		{"0 0 * * *", nil, nil, nil},
+		{"60 24 * * *", nil, []cron.Field{cron.FieldMinutes, cron.FieldHours}, []cron.ErrorCode{cron.CodeOutOfRange, cron.CodeOutOfRange}},
+		{"x 0 32 13 8", nil,
+			[]cron.Field{cron.FieldMinutes, cron.FieldDaysOfMonth, cron.FieldMonths, cron.FieldDaysOfWeek},
+			[]cron.ErrorCode{cron.CodeSyntax, cron.CodeOutOfRange, cron.CodeOutOfRange, cron.CodeOutOfRange}},
+		{"60 0 30 2 *", nil, []cron.Field{cron.FieldMinutes, cron.FieldDaysOfMonth}, []cron.ErrorCode{cron.CodeOutOfRange, cron.CodeImpossibleDate}},
+		{"0 0 30 14 *", nil, []cron.Field{cron.FieldMonths}, []cron.ErrorCode{cron.CodeOutOfRange}},
+		{"0 0 * * mn", nil, []cron.Field{cron.FieldDaysOfWeek}, []cron.ErrorCode{cron.CodeSyntax}},
+		{"0 0 1 * 1#2,x", nil, []cron.Field{cron.FieldDaysOfWeek}, []cron.ErrorCode{cron.CodeSyntax}},
+		{"0 0 *", nil, []cron.Field{cron.FieldMonths, cron.FieldDaysOfWeek}, []cron.ErrorCode{cron.CodeEmpty, cron.CodeEmpty}},
+		{"0 0 L * * *", []cron.Option{cron.WithDialect(cron.DialectPOSIX)},
+			[]cron.Field{cron.FieldDaysOfMonth, cron.FieldYears}, []cron.ErrorCode{cron.CodeNotSupported, cron.CodeTooManyFields}},
+	}
+	for _, tt := range tests {
+		_, err := cron.Parse(tt.expr, append(tt.opts, cron.WithAllErrors())...)
+		if tt.fields == nil {
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+		var perrs cron.ParseErrors
+		if !errors.As(err, &perrs) {
+			t.Errorf("%q: expected ParseErrors, got %v", tt.expr, err)
+			continue
+		}
+		var fields []cron.Field
+		var codes []cron.ErrorCode
+		for _, perr := range perrs {
+			fields = append(fields, perr.Field)
+			codes = append(codes, perr.Code)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) || !reflect.DeepEqual(codes, tt.codes) {
+			t.Errorf("%q: wrong errors\ngot:  %v %v\nwant: %v %v", tt.expr, fields, codes, tt.fields, tt.codes)
+		}
+
+		// The first error is found as with a single one.
+		var perr *cron.ParseError
+		if !errors.As(err, &perr) || perr != perrs[0] {
+			t.Errorf("%q: expected errors.As to find the first error, got %v", tt.expr, perr)
+		}
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	tests := []struct {
+		expr string
+		opts []cron.Option
+		want string
+	}{
+		{"0 0 * * mn", nil, `did you mean "mon"?`},
+		{"0 0 * jnu *", nil, `did you mean "jun"?`},
+		{"0 0 * Sept *", nil, `did you mean "sep"?`},
+		{"0 0 * * thurs", nil, `did you mean "thu"?`},
+		{"0 0 * * wensday", nil, `did you mean "wednesday"?`},
+		{"0 0 * * fri#2,satL,sundy", nil, `did you mean "sunday"?`},
+		{"0 0 * * xyz", nil, ""},
+		{"0 0 * * 1 3000", nil, ""},
+		{"cron(0 10 * * ? 3000)", []cron.Option{cron.WithDialect(cron.DialectEventBridge)}, ""},
+		{"0 22-2 * * *", []cron.Option{cron.WithDialect(cron.DialectKubernetes)}, `ranges cannot wrap around; did you mean "22-23,0-2"?`},
+		{"0 0 * january *", []cron.Option{cron.WithDialect(cron.DialectKubernetes)}, `did you mean "jan"?`},
+		{"0 0 0 ? * Thursday", []cron.Option{cron.WithDialect(cron.DialectQuartz)}, `did you mean "thu"?`},
+		{"0 0 ? * mon", nil, `"?" is Quartz syntax; use "*", or parse with WithDialect(DialectQuartz)`},
+		{"0 30 9 * * mon", nil, "six fields found; for a leading seconds field, parse with WithSeconds"},
+		{"0 30 9 * * mon", []cron.Option{cron.WithDialect(cron.DialectPOSIX)}, "six fields found; for a leading seconds field, parse with WithSeconds"},
+		{"30 9 * * mon", []cron.Option{cron.WithSeconds()}, "five fields found, but a leading seconds field expected"},
+		{"*/0 * * * *", nil, `steps start at 1; did you mean "*"?`},
+		{"0 1,5-10/00 * * *", nil, `steps start at 1; did you mean "5-10"?`},
+		{"cron(*/0 * * * ? *)", []cron.Option{cron.WithDialect(cron.DialectEventBridge)}, `steps start at 1; did you mean "*"?`},
+		{"*/70 * * * *", nil, ""},
+		{"H(30-10) * * * *", []cron.Option{cron.WithHash("key")}, `did you mean "H(10-30)"?`},
+		{"50~10 * * * *", []cron.Option{cron.WithRandom(nil)}, `did you mean "10~50"?`},
+	}
+	for _, tt := range tests {
+		_, err := cron.Parse(tt.expr, tt.opts...)
+		var perr *cron.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: expected a *ParseError, got %v", tt.expr, err)
+			continue
+		}
+		if perr.Suggestion != tt.want {
+			t.Errorf("%q: wrong suggestion\ngot:  %q\nwant: %q", tt.expr, perr.Suggestion, tt.want)
+		}
+	}
+}
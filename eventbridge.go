@@ -0,0 +1,79 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cutEventBridge returns the fields of an EventBridge "cron(fields)"
+// expression, or the interval of a "rate(n unit)" expression.
+func cutEventBridge(expr string) (fields string, every time.Duration, err error) {
+	if !strings.HasSuffix(expr, ")") {
+		return "", 0, errors.New("expected cron(...) or rate(...)")
+	}
+	switch {
+	case strings.HasPrefix(expr, "cron("):
+		return expr[len("cron(") : len(expr)-1], 0, nil
+	case strings.HasPrefix(expr, "rate("):
+		every, err = parseRate(expr[len("rate(") : len(expr)-1])
+		return "", every, err
+	default:
+		return "", 0, errors.New("expected cron(...) or rate(...)")
+	}
+}
+
+var rateUnits = [...]struct {
+	singular, plural string
+	d                time.Duration
+}{
+	{"day", "days", 24 * time.Hour},
+	{"hour", "hours", time.Hour},
+	{"minute", "minutes", time.Minute},
+}
+
+// parseRate parses the "n unit" of an EventBridge rate expression, where the
+// unit is singular if n is 1, and plural otherwise.
+func parseRate(rate string) (time.Duration, error) {
+	value, unit, _ := strings.Cut(rate, " ")
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || value[0] == '+' {
+		return 0, fmt.Errorf("invalid rate value %q", value)
+	}
+	for _, u := range rateUnits {
+		if n == 1 && unit == u.singular || n > 1 && unit == u.plural {
+			return time.Duration(n) * u.d, nil
+		}
+	}
+	return 0, fmt.Errorf("unexpected rate unit %q", unit)
+}
+
+// renderEventBridge implements Render for DialectEventBridge.
+func (e *Expr) renderEventBridge() (string, error) {
+	if e.every != 0 {
+		for _, u := range rateUnits {
+			if n := e.every / u.d; e.every%u.d == 0 {
+				if n == 1 {
+					return fmt.Sprintf("rate(1 %s)", u.singular), nil
+				}
+				return fmt.Sprintf("rate(%d %s)", n, u.plural), nil
+			}
+		}
+		return "", errors.New("interval not a whole number of minutes")
+	}
+	if e.loc != nil && e.loc.String() != "UTC" {
+		return "", errors.New("time zones other than UTC not supported")
+	}
+	if e.s != 1 {
+		return "", errors.New("seconds not supported")
+	}
+	dom, dow, err := e.formatQuartzDays()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cron(%s %s %s %s %s %s)",
+		formatField(e.m, 0, 59), formatField(uint64(e.h), 0, 23), dom,
+		formatField(uint64(e.mon), 1, 12), dow, e.formatYears()), nil
+}
@@ -0,0 +1,156 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"fmrsn.com/cron"
+)
+
+func TestEventBridge(t *testing.T) {
+	eventBridge := cron.NewParser(cron.WithDialect(cron.DialectEventBridge))
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	utc := func(y int, mon time.Month, d, h, m int) time.Time {
+		return time.Date(y, mon, d, h, m, 0, 0, time.UTC)
+	}
+
+	// Examples from the EventBridge documentation, starting on Saturday
+	// 2022-01-01 in Berlin, which EventBridge ignores.
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, berlin)
+	tests := []struct {
+		expr string
+		next []time.Time
+	}{{
+		expr: "cron(0 10 * * ? *)",
+		next: []time.Time{utc(2022, 1, 1, 10, 0), utc(2022, 1, 2, 10, 0)},
+	}, {
+		expr: "cron(15 12 * * ? *)",
+		next: []time.Time{utc(2022, 1, 1, 12, 15), utc(2022, 1, 2, 12, 15)},
+	}, {
+		expr: "cron(0 18 ? * MON-FRI *)",
+		next: []time.Time{utc(2022, 1, 3, 18, 0), utc(2022, 1, 4, 18, 0)},
+	}, {
+		expr: "cron(0 8 1 * ? *)",
+		next: []time.Time{utc(2022, 1, 1, 8, 0), utc(2022, 2, 1, 8, 0)},
+	}, {
+		expr: "cron(0/10 * ? * MON-FRI *)",
+		next: []time.Time{utc(2021, 12, 31, 23, 10), utc(2021, 12, 31, 23, 20)},
+	}, {
+		expr: "cron(0/5 8-17 ? * MON-FRI *)",
+		next: []time.Time{utc(2022, 1, 3, 8, 0), utc(2022, 1, 3, 8, 5)},
+	}, {
+		expr: "cron(0 9 ? * 2#1 *)",
+		next: []time.Time{utc(2022, 1, 3, 9, 0), utc(2022, 2, 7, 9, 0)},
+	}, {
+		expr: "cron(0 0 L * ? 2022-2023)",
+		next: []time.Time{utc(2022, 1, 31, 0, 0), utc(2022, 2, 28, 0, 0)},
+	}, {
+		expr: "rate(5 minutes)",
+		next: []time.Time{utc(2021, 12, 31, 23, 5), utc(2021, 12, 31, 23, 10)},
+	}, {
+		expr: "rate(1 day)",
+		next: []time.Time{utc(2022, 1, 1, 0, 0), utc(2022, 1, 2, 0, 0)},
+	}}
+	for _, tt := range tests {
+		expr, err := eventBridge.Parse(tt.expr)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		got := from
+		for _, want := range tt.next {
+			if got = expr.Next(got); !got.Equal(want) || got.Location() != time.UTC {
+				t.Errorf("%q: wrong next\ngot:  %v\nwant: %v", tt.expr, got, want)
+				break
+			}
+		}
+	}
+
+	for _, expr := range []string{
+		"0 10 * * ? *",
+		"cron(0 10 * * ?)",        // No years field.
+		"cron(0 10 * * * *)",      // No "?".
+		"cron(0 10 ? * ? *)",      // Two "?".
+		"cron(0 0 10 * * ? *)",    // Seconds.
+		"cron(0 10 ? * 0 *)",      // Sunday is 1.
+		"cron(0 10 L,1 * ? *)",    // L with other days.
+		"cron(0 10 * * ? 2200)",   // Years end at 2199.
+		"cron(@daily)",            // Macros.
+		"cron(TZ=UTC 0 10 * * ?)", // Time zones.
+		"cron(0 10 * * ? *",
+		"rate(5 minute)",
+		"rate(1 minutes)",
+		"rate(0 minutes)",
+		"rate(-5 minutes)",
+		"rate(+5 minutes)",
+		"rate(5 seconds)",
+		"rate(5)",
+		"rate()",
+	} {
+		if _, err := eventBridge.Parse(expr); err == nil {
+			t.Errorf("expected Parse to reject %q", expr)
+		}
+	}
+}
+
+func TestRenderEventBridge(t *testing.T) {
+	tests := []struct {
+		expr string
+		opts []cron.Option
+		want string
+	}{
+		{"0 12 * * *", nil, "cron(0 12 * * ? *)"},
+		{"*/15 9-17 * * mon-fri", nil, "cron(*/15 9-17 ? * 2-6 *)"},
+		{"5,35 0 1,15 * *", nil, "cron(5,35 0 1,15 * ? *)"},
+		{"0 0 L * *", nil, "cron(0 0 L * ? *)"},
+		{"0 0 L-3W * *", nil, "cron(0 0 L-3W * ? *)"},
+		{"0 0 15W * *", nil, "cron(0 0 15W * ? *)"},
+		{"0 0 * * fri#3", nil, "cron(0 0 ? * 6#3 *)"},
+		{"0 0 * * 0L", nil, "cron(0 0 ? * 1L *)"},
+		{"0 0 1 1 * 2022-2030/4", nil, "cron(0 0 1 1 ? 2022-2030/4)"},
+		{"0 0 1 1 * 2022,2030", nil, "cron(0 0 1 1 ? 2022,2030)"},
+		{"0 0 * 1-3,6,7,9 sun", nil, "cron(0 0 ? 1-3,6,7,9 1 *)"},
+		{"0 0 1 * mon", []cron.Option{cron.WithDaysOr()}, ""},
+		{"0 0 * * mon", []cron.Option{cron.WithDaysOr()}, "cron(0 0 ? * 2 *)"},
+		{"0 0 1 * mon", nil, ""},
+		{"0 0 L,15 * *", nil, ""},
+		{"@every 90m", nil, "rate(90 minutes)"},
+		{"@every 2h", nil, "rate(2 hours)"},
+		{"@every 24h", nil, "rate(1 day)"},
+		{"@every 90s", nil, ""},
+		{"30 0 0 * * *", []cron.Option{cron.WithSeconds()}, ""},
+		{"TZ=Europe/Berlin 0 0 * * *", nil, ""},
+		{"TZ=UTC 0 0 * * *", nil, "cron(0 0 * * ? *)"},
+	}
+	for _, tt := range tests {
+		expr := cron.MustParse(tt.expr, tt.opts...)
+		got, err := expr.Render(cron.DialectEventBridge)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("expected Render to reject %q, got %q", tt.expr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("wrong rendering of %q\ngot:  %q\nwant: %q", tt.expr, got, tt.want)
+			continue
+		}
+
+		// The rendered expression activates at the same times.
+		rendered := cron.NewParser(cron.WithDialect(cron.DialectEventBridge)).MustParse(got)
+		from, want := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 10; i++ {
+			if from, want = rendered.Next(from), expr.Next(want); !from.Equal(want) {
+				t.Errorf("%q and %q disagree\ngot:  %v\nwant: %v", tt.expr, got, from, want)
+				break
+			}
+		}
+	}
+}
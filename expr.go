@@ -3,98 +3,738 @@ package cron
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"math/bits"
+	"math/rand"
 	"strconv"
 	"strings"
 	"time"
 )
 
-type fieldType int
+// A Field identifies a field of a cron expression.
+type Field int
 
 const (
-	fieldMinutes fieldType = iota
-	fieldHours
-	fieldDaysOfMonth
-	fieldMonths
-	fieldDaysOfWeek
+	FieldSeconds Field = iota
+	FieldMinutes
+	FieldHours
+	FieldDaysOfMonth
+	FieldMonths
+	FieldDaysOfWeek
+	FieldYears
+
+	// FieldPrefix is what comes before the fields, or stands for them: a
+	// time zone prefix, e.g., "TZ=UTC", or a macro, e.g., "@daily".
+	FieldPrefix
 )
 
-func (t fieldType) String() string {
+func (t Field) String() string {
 	switch t {
-	case fieldMinutes:
+	case FieldSeconds:
+		return "seconds"
+	case FieldMinutes:
 		return "minutes"
-	case fieldHours:
+	case FieldHours:
 		return "hours"
-	case fieldDaysOfMonth:
+	case FieldDaysOfMonth:
 		return "days of month"
-	case fieldMonths:
+	case FieldMonths:
 		return "months"
-	case fieldDaysOfWeek:
+	case FieldDaysOfWeek:
 		return "days of week"
+	case FieldYears:
+		return "years"
+	case FieldPrefix:
+		return "prefix"
 	default:
 		return strconv.FormatInt(int64(t), 10)
 	}
 }
 
 type Expr struct {
-	expr string
-	m    uint64 // 0-59
-	h    uint32 // 0-23
-	dom  uint32 // 1-31
-	mon  uint16 // 1-12
-	dow  uint8  // 0-6 (0=Sunday)
+	expr  string
+	s     uint64   // 0-59
+	m     uint64   // 0-59
+	h     uint32   // 0-23
+	dom   uint32   // 1-31
+	domL  uint32   // 0-30, days before the last day of the month ("L-n")
+	domW  uint32   // like dom, but matching the nearest weekday ("nW")
+	domLW uint32   // like domL, but matching the nearest weekday ("L-nW")
+	mon   uint16   // 1-12
+	dow   uint8    // 0-6 (0=Sunday)
+	dowN  [5]uint8 // like dow, but matching the nth day of week of the month ("d#n")
+	dowL  uint8    // like dow, but matching the last day of week of the month ("dL")
+	y     years    // 1970-2199; empty if there is no years field
+
+	// lastYear is the last year in which e activates if y is empty, or 0 if
+	// there is none, e.g., 2099 under DialectQuartz.
+	lastYear int
+
+	// loc is the time zone in which Next and Prev evaluate e, if given with
+	// a "CRON_TZ=" or "TZ=" prefix.
+	loc *time.Location
+
+	// random holds the values chosen for "~" groups.
+	random []RandomValue
+
+	// dst is the policy for activation times skipped or repeated by daylight
+	// saving time transitions.
+	dst DSTPolicy
+
+	// daysOr reports whether a day matches if either the days of month or
+	// the days of week fields match, instead of both.
+	daysOr bool
+
+	// wildcardDays reports whether either days field starts with "*", in
+	// which case POSIX cron, too, matches days in both fields.
+	wildcardDays bool
+
+	// seconds reports whether the expression has a seconds field, in which
+	// case Next and Prev have a precision of one second instead of one
+	// minute.
+	seconds bool
+
+	// every is the interval between activations of an "@every" expression,
+	// which ignores the fields above. Activations happen at anchor plus a
+	// multiple of every.
+	every  time.Duration
+	anchor time.Time
+}
+
+// An Option configures how Parse interprets a cron expression.
+type Option func(*options)
+
+type options struct {
+	seconds  bool
+	daysOr   bool
+	anchor   time.Time
+	hash     bool
+	hashKey  string
+	random   bool
+	rand     *rand.Rand
+	dst      DSTPolicy
+	features Feature
+	maxYear  int
+
+	// allErrors makes Parse go on after an invalid field.
+	allErrors bool
+
+	// quartz enables the Quartz syntax of the days fields: "?" standing for
+	// any day in exactly one of them, days of week from 1 (Sunday) to 7
+	// (Saturday), "L" alone standing for Saturday, and L, W and # groups
+	// standing alone in their field.
+	quartz bool
+
+	// eventBridge enables the "cron(...)" and "rate(...)" syntax of
+	// EventBridge.
+	eventBridge bool
+
+	// systemd enables the calendar event syntax of systemd.
+	systemd bool
+
+	// kubernetes enables the syntax of robfig/cron, which Kubernetes uses:
+	// "?" standing for "*" in any field, ranges that do not wrap around, and
+	// three-letter names only. It also makes WithDaysOr follow its rule: a
+	// days field is unrestricted only if one of its groups is "*" or "?", so
+	// that "*/2" is restricted.
+	kubernetes bool
+
+	// chosen collects the values picked for "~" groups while parsing.
+	chosen []RandomValue
+}
+
+// WithSeconds makes Parse expect a leading seconds field, for a total of six
+// fields, e.g., "*/15 * * * * *" runs every fifteen seconds.
+func WithSeconds() Option {
+	return func(o *options) {
+		o.seconds = true
+	}
+}
+
+// WithDaysOr makes Parse follow the POSIX and Vixie cron rule for days: if
+// both the days of month and the days of week fields are restricted, that
+// is, neither starts with an asterisk, a day matches if either field
+// matches, e.g., "0 0 1,15 * mon" runs on the 1st, on the 15th, and on every
+// Monday. Otherwise, both fields must match, which is always the case
+// without this option.
+func WithDaysOr() Option {
+	return func(o *options) {
+		o.daysOr = true
+	}
+}
+
+// WithHash enables the Jenkins "H" syntax, which stands for a value derived
+// from a hash of key, typically the name of a job, so that jobs sharing the
+// same expression don't all run at once:
+//
+//	H          a value in the range of the field
+//	H(a-b)     a value in the range a-b
+//	H/n        every n units, starting from a value in the range 0-(n-1)
+//	H(a-b)/n   every n units in the range a-b, starting from a value in the
+//	           range a-(a+n-1)
+//
+// Unlike "*", "H" ranges from 1 to 28 in the days of month field, so that it
+// matches every month.
+func WithHash(key string) Option {
+	return func(o *options) {
+		o.hash = true
+		o.hashKey = key
+	}
+}
+
+// WithRandom enables the OpenBSD "~" syntax, which stands for a value picked
+// at random from r when parsing:
+//
+//	~          a value in the range of the field
+//	a~b        a value in the range a-b
+//	a~         a value in the range from a to the end of the field
+//	~b         a value in the range from the start of the field to b
+//
+// If r is nil, the default source of math/rand is used. The values picked are
+// reported by Expr.RandomValues.
+func WithRandom(r *rand.Rand) Option {
+	return func(o *options) {
+		o.random = true
+		o.rand = r
+	}
+}
+
+// WithAnchor sets the time from which the activations of an "@every"
+// expression are counted. By default, they are counted from the Unix epoch,
+// so that, e.g., "@every 90m" activates at the same instants regardless of
+// when it was parsed.
+func WithAnchor(t time.Time) Option {
+	return func(o *options) {
+		o.anchor = t
+	}
+}
+
+// WithAllErrors makes Parse check every field of an expression, and the
+// impossible-date check, instead of stopping at the first invalid one. The
+// error then wraps a ParseErrors listing every invalid field.
+func WithAllErrors() Option {
+	return func(o *options) {
+		o.allErrors = true
+	}
+}
+
+// A DSTPolicy defines how Next and Prev handle activation times whose wall
+// clock time is skipped or repeated by a daylight saving time transition. It
+// combines either SkipGap or ShiftGap with either OnceInOverlap or
+// TwiceInOverlap. The zero value is SkipGap|OnceInOverlap.
+//
+// Vixie cron runs fixed-time jobs, whose minutes and hours fields are not
+// wildcards, as with ShiftGap|OnceInOverlap, except that it runs those skipped
+// right after the gap, and other jobs as with SkipGap|TwiceInOverlap.
+type DSTPolicy uint8
+
+const (
+	// SkipGap skips activation times that fall in the gap left when clocks
+	// move forward, e.g., 02:30 is skipped when clocks move from 02:00 to
+	// 03:00.
+	SkipGap DSTPolicy = 0
+
+	// ShiftGap shifts activation times that fall in a gap forward by the
+	// length of the gap, e.g., 02:30 activates at 03:30 when clocks move from
+	// 02:00 to 03:00.
+	ShiftGap DSTPolicy = 1 << 0
+
+	// OnceInOverlap activates only the first time a wall clock time repeated
+	// when clocks move backward occurs, e.g., 01:30 activates before, but not
+	// after, clocks move from 02:00 back to 01:00.
+	OnceInOverlap DSTPolicy = 0
+
+	// TwiceInOverlap activates both times a repeated wall clock time occurs.
+	TwiceInOverlap DSTPolicy = 1 << 1
+)
+
+// WithDST sets the policy for activation times skipped or repeated by daylight
+// saving time transitions in the location in which Next and Prev evaluate the
+// expression. By default, it is SkipGap|OnceInOverlap.
+func WithDST(p DSTPolicy) Option {
+	return func(o *options) {
+		o.dst = p
+	}
+}
+
+// A Feature is an optional part of the syntax accepted by Parse.
+type Feature uint
+
+const (
+	// FeatureYears is the optional trailing years field.
+	FeatureYears Feature = 1 << iota
+
+	// FeatureMacros is the "@yearly", "@daily", etc. macros, and "@every
+	// <duration>" intervals.
+	FeatureMacros
+
+	// FeatureModifiers is the L, W and # modifiers of the days fields.
+	FeatureModifiers
+
+	// FeatureSunday7 is 7 standing for Sunday in the days of week field,
+	// besides 0.
+	FeatureSunday7
+
+	// FeatureTimeZone is the "CRON_TZ=" and "TZ=" prefixes.
+	FeatureTimeZone
+
+	// AllFeatures is every feature above, all of which Parse accepts by
+	// default.
+	AllFeatures = FeatureYears | FeatureMacros | FeatureModifiers | FeatureSunday7 | FeatureTimeZone
+)
+
+// WithFeatures restricts the optional parts of the syntax that Parse accepts
+// to those in f, e.g., WithFeatures(AllFeatures &^ FeatureYears) rejects a
+// years field.
+func WithFeatures(f Feature) Option {
+	return func(o *options) {
+		o.features = f
+	}
+}
+
+// A Dialect is a preset of options matching the cron syntax of a system. See
+// WithDialect.
+type Dialect int
+
+const (
+	// DialectDefault is the syntax accepted by Parse without options.
+	DialectDefault Dialect = iota
+
+	// DialectPOSIX is the POSIX crontab syntax: five fields, days matching as
+	// with WithDaysOr, and no optional features.
+	DialectPOSIX
+
+	// DialectVixie is the syntax of Vixie cron and its descendants, such as
+	// cronie: POSIX plus macros, 7 for Sunday and time zone prefixes.
+	DialectVixie
+
+	// DialectKubernetes is the syntax of the schedule of a Kubernetes CronJob:
+	// five fields, days matching as with WithDaysOr, and macros. Its time zone
+	// is set apart, in the timeZone field of the CronJob. As in robfig/cron,
+	// which Kubernetes uses, "?" stands for "*", ranges cannot wrap around,
+	// names have three letters, and a days field is restricted unless one of
+	// its groups is "*" or "?", e.g., "0 0 1 * */2" runs on the 1st and on
+	// every other day of week.
+	DialectKubernetes
+
+	// DialectQuartz is the syntax of the Quartz scheduler for Java: a leading
+	// seconds field, an optional years field up to 2099, the L, W and #
+	// modifiers, and "?" in exactly one of the days fields, which stands for
+	// any day. Days of week range from 1 (Sunday) to 7 (Saturday), and "L"
+	// alone stands for Saturday. Unlike Quartz, Parse rejects expressions
+	// that never activate, such as "0 0 0 30 2 ?".
+	DialectQuartz
+
+	// DialectEventBridge is the syntax of the schedule expressions of Amazon
+	// EventBridge rules: "cron(fields)", where fields are as in DialectQuartz,
+	// but without the seconds field and with a mandatory years field up to
+	// 2199, or "rate(n unit)", where unit is minute(s), hour(s) or day(s),
+	// which activates every n units from the anchor set with WithAnchor. Next
+	// and Prev evaluate EventBridge expressions in UTC.
+	DialectEventBridge
+
+	// DialectSystemd is the syntax of the calendar events of systemd timers,
+	// as in OnCalendar=, e.g., "Mon..Fri *-*-* 09:00:00", "*-*-01 03:00" or
+	// "weekly". An optional trailing time zone, such as "Europe/Berlin",
+	// sets the location in which Next and Prev evaluate it.
+	DialectSystemd
+)
+
+// String returns the name of the dialect.
+func (d Dialect) String() string {
+	switch d {
+	case DialectDefault:
+		return "default"
+	case DialectPOSIX:
+		return "POSIX"
+	case DialectVixie:
+		return "Vixie"
+	case DialectKubernetes:
+		return "Kubernetes"
+	case DialectQuartz:
+		return "Quartz"
+	case DialectEventBridge:
+		return "EventBridge"
+	case DialectSystemd:
+		return "systemd"
+	default:
+		return strconv.FormatInt(int64(d), 10)
+	}
+}
+
+// WithDialect sets the options of Parse to those of the dialect d. Options
+// given after it override them, e.g., WithDialect(DialectPOSIX),
+// WithSeconds() accepts POSIX expressions with a leading seconds field.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.seconds = d == DialectQuartz || d == DialectSystemd
+		o.daysOr = d == DialectPOSIX || d == DialectVixie || d == DialectKubernetes
+		o.maxYear = maxYear
+		o.quartz = d == DialectQuartz || d == DialectEventBridge
+		o.eventBridge = d == DialectEventBridge
+		o.systemd = d == DialectSystemd
+		o.kubernetes = d == DialectKubernetes
+		switch d {
+		case DialectDefault:
+			o.features = AllFeatures
+		case DialectPOSIX:
+			o.features = 0
+		case DialectVixie:
+			o.features = FeatureMacros | FeatureSunday7 | FeatureTimeZone
+		case DialectKubernetes:
+			o.features = FeatureMacros
+		case DialectQuartz:
+			o.features = FeatureYears | FeatureModifiers
+			o.maxYear = 2099
+		case DialectEventBridge, DialectSystemd:
+			o.features = FeatureYears | FeatureModifiers
+		}
+	}
 }
 
-func MustParse(expr string) Expr {
-	e, err := Parse(expr)
+// A Parser parses cron expressions with a fixed set of options, e.g., those of
+// a Dialect. A Parser must be created with NewParser, and is safe for
+// concurrent use unless created with WithRandom and a non-nil source.
+type Parser struct {
+	opts options
+}
+
+// NewParser returns a Parser with the given options.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{opts: options{anchor: time.Unix(0, 0), features: AllFeatures, maxYear: maxYear}}
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+	return p
+}
+
+// MustParse is like Parse, but panics if expr cannot be parsed.
+func (p *Parser) MustParse(expr string) Expr {
+	e, err := p.Parse(expr)
 	if err != nil {
 		panic(err)
 	}
 	return e
 }
 
-func Parse(expr string) (e Expr, err error) {
+func MustParse(expr string, opts ...Option) Expr {
+	e, err := Parse(expr, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Parse parses a cron expression made of the following fields, separated by
+// single spaces:
+//
+//	Field          Values          Special characters
+//	seconds        0-59            * / , -          (only with WithSeconds)
+//	minutes        0-59            * / , -
+//	hours          0-23            * / , -
+//	days of month  1-31            * / , - L W
+//	months         1-12 or names   * / , -
+//	days of week   0-7 or names    * / , - L #      (0 or 7=Sunday)
+//	years          1970-2199       * / , -          (optional)
+//
+// By default, a day matches if it matches both the days of month and the
+// days of week fields; see WithDaysOr for the POSIX behavior. Every field also
+// accepts "H" if a hash key is given with WithHash, and "~" if a random source
+// is given with WithRandom.
+//
+// Parse also accepts the "@yearly", "@annually", "@monthly", "@weekly",
+// "@daily", "@midnight" and "@hourly" macros, and "@every <duration>"
+// intervals.
+//
+// An expression may be prefixed with "CRON_TZ=<zone> " or "TZ=<zone> ", where
+// zone is an IANA time zone name such as "Europe/Berlin", in which case Next
+// and Prev evaluate it in that time zone, regardless of the location of the
+// time given to them. Wall clock times skipped or repeated by daylight saving
+// time transitions are handled as set with WithDST.
+//
+// Options restrict or extend this syntax, e.g., WithDialect selects that of
+// another system. To parse many expressions with the same options, use a
+// Parser.
+//
+// If a field is invalid, the error wraps a *ParseError, which locates the
+// offending token in expr.
+func Parse(expr string, opts ...Option) (Expr, error) {
+	return NewParser(opts...).Parse(expr)
+}
+
+// Parse parses a cron expression as the package-level Parse does, with the
+// options of p.
+func (p *Parser) Parse(expr string) (e Expr, err error) {
 	defer func() {
 		if err != nil {
-			err = fmt.Errorf("cron: parsing %q: %v", expr, err)
+			err = fmt.Errorf("cron: parsing %q: %w", expr, err)
 		}
 	}()
 
-	m, h, dom, mon, dow := splitFields(expr)
+	o := p.opts
 
-	parseField := func(groups string, typ fieldType, min, max int) (field uint64) {
+	// base is the offset of body in expr, or -1 if body is rewritten, in
+	// which case the tokens of parse errors span the whole expression.
+	// Missing fields are found at the end of body.
+	body, base := expr, 0
+	defer func() {
+		perrs, _ := err.(ParseErrors)
+		if perr, ok := err.(*ParseError); ok {
+			perrs = ParseErrors{perr}
+		}
+		for _, perr := range perrs {
+			switch {
+			case base < 0:
+				perr.Offset, perr.Len = 0, len(expr)
+			case perr.Offset > base+len(body):
+				perr.Offset, perr.Len = base+len(body), 0
+			}
+			if base >= 0 && perr.Field != FieldPrefix {
+				perr.suggest(expr, base, strings.Count(body, " ")+1, &o)
+			}
+		}
+	}()
+	if o.eventBridge {
+		var every time.Duration
+		if body, every, err = cutEventBridge(body); err != nil {
+			return e, err
+		}
+		base = len("cron(")
+		e.loc = time.UTC
+		if every != 0 {
+			e.expr = expr
+			e.every = every
+			e.anchor = o.anchor
+			return e, nil
+		}
+	}
+	if o.systemd {
+		var zone string
+		if body, zone, err = cutSystemd(body); err != nil {
+			return e, err
+		}
+		base = -1
+		if zone != "" {
+			if e.loc, err = time.LoadLocation(zone); err != nil {
+				return e, err
+			}
+		}
+	}
+	for _, prefix := range [...]string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(body, prefix) {
+			var name, rest string
+			name, rest, _ = strings.Cut(body[len(prefix):], " ")
+			if o.features&FeatureTimeZone == 0 {
+				return e, &ParseError{Field: FieldPrefix, Code: CodeNotSupported, Offset: base, Len: len(prefix) + len(name), Err: errors.New("time zone prefix not supported")}
+			}
+			if name == "" {
+				return e, &ParseError{Field: FieldPrefix, Code: CodeEmpty, Offset: base, Len: len(prefix), Err: errors.New("empty time zone")}
+			}
+			base += len(body) - len(rest)
+			body = rest
+			if e.loc, err = time.LoadLocation(name); err != nil {
+				return e, err
+			}
+			break
+		}
+	}
+
+	if strings.HasPrefix(body, "@") && o.features&FeatureMacros == 0 {
+		return e, &ParseError{Field: FieldPrefix, Code: CodeNotSupported, Offset: base, Len: len(body), Err: errors.New("macros not supported")}
+	}
+	if every, ok := cutPrefixFold(body, "@every "); ok {
+		if e.every, err = time.ParseDuration(every); err != nil {
+			return e, err
+		}
+		if e.every <= 0 {
+			return e, errors.New("non-positive interval")
+		}
+		e.expr = expr
+		e.anchor = o.anchor
+		return e, nil
+	}
+
+	s, rest := "0", body
+	if strings.HasPrefix(body, "@") {
+		var ok bool
+		if rest, ok = expandMacro(body); !ok {
+			return e, &ParseError{Field: FieldPrefix, Code: CodeSyntax, Offset: base, Len: len(body), Err: errors.New("unknown macro")}
+		}
+		base = -1
+	} else if o.seconds {
+		s, rest, _ = strings.Cut(rest, " ")
+	}
+	m, h, dom, mon, dow := splitFields(rest)
+	dow, y, hasYears := strings.Cut(dow, " ")
+
+	// The offsets of the fields in expr, each one following the previous one
+	// and a space.
+	var offsets [FieldYears + 1]int
+	for i, field := range [...]string{s, m, h, dom, mon, dow} {
+		offsets[i+1] = offsets[i] + len(field) + 1
+	}
+	if !o.seconds {
+		offsets[FieldSeconds] = -1
+		for i := FieldMinutes; i <= FieldYears; i++ {
+			offsets[i] -= len(s) + 1
+		}
+	}
+	for i := range offsets {
+		offsets[i] += base
+	}
+	// Quartz requires "?" in exactly one of the days fields, e.g., in the
+	// days of month field if it is "*", since "0 0 12 * * MON" is a common
+	// mistake.
+	var daysErr *ParseError
+	if o.quartz {
+		if (dom == "?") == (dow == "?") {
+			daysErr = &ParseError{Field: FieldDaysOfWeek, Code: CodeSyntax, Len: len(dow), Err: errors.New("exactly one of the days fields must be ?")}
+			if dom == "*" {
+				daysErr.Field, daysErr.Len = FieldDaysOfMonth, len(dom)
+			}
+		}
+		if dom == "?" {
+			dom = "*"
+		} else {
+			dow = "*"
+		}
+	}
+
+	// Parse the fields in order, up to the first invalid one, or every one
+	// of them with WithAllErrors.
+	var perrs ParseErrors
+	check := func(typ Field, ferr error) {
+		if ferr == nil {
+			return
+		}
+		ferr = shiftError(ferr, offsets[typ])
 		if err == nil {
-			field, err = parseField(groups, typ, min, max)
+			err = ferr
+		}
+		if perr, ok := ferr.(*ParseError); ok {
+			perrs = append(perrs, perr)
+		}
+	}
+	next := func() bool {
+		return err == nil || o.allErrors
+	}
+	parseField := func(groups string, typ Field, min, max int) (field uint64) {
+		if next() {
+			var ferr error
+			field, ferr = o.parseField(groups, typ, min, max)
+			check(typ, ferr)
 		}
 		return
 	}
-	e.m = parseField(m, fieldMinutes, 0, 59)
-	e.h = uint32(parseField(h, fieldHours, 0, 23))
-	e.dom = uint32(parseField(dom, fieldDaysOfMonth, 1, 31))
-	e.mon = uint16(parseField(mon, fieldMonths, 1, 12))
-	e.dow = uint8(parseField(dow, fieldDaysOfWeek, 0, 6))
-	if err != nil {
-		return e, err
+	e.s = parseField(s, FieldSeconds, 0, 59)
+	e.m = parseField(m, FieldMinutes, 0, 59)
+	e.h = uint32(parseField(h, FieldHours, 0, 23))
+	if next() {
+		var ferr error
+		e.dom, e.domL, e.domW, e.domLW, ferr = o.parseDaysOfMonth(dom)
+		check(FieldDaysOfMonth, ferr)
 	}
-
-	// Detect impossible combinations of month/day pairs, e.g., February 30th.
-	const monthsWith31Days = 1<<1 | 1<<3 | 1<<5 | 1<<7 | 1<<8 | 1<<10 | 1<<12
-	if e.mon&monthsWith31Days == 0 {
-		febOnly := e.mon == 1<<2
-		domAllowed := uint32(1)<<31 - 1<<1
-		if febOnly {
-			domAllowed = uint32(1)<<30 - 1<<1
+	if next() && daysErr != nil {
+		check(daysErr.Field, daysErr)
+	}
+	e.mon = uint16(parseField(mon, FieldMonths, 1, 12))
+	if next() {
+		var ferr error
+		e.dow, e.dowN, e.dowL, ferr = o.parseDaysOfWeek(dow)
+		check(FieldDaysOfWeek, ferr)
+	}
+	if next() && hasYears && o.features&FeatureYears == 0 {
+		check(FieldYears, &ParseError{Field: FieldYears, Code: CodeTooManyFields, Len: len(y), Err: errors.New("too many fields")})
+	}
+	if next() && !hasYears && o.eventBridge {
+		check(FieldYears, &ParseError{Field: FieldYears, Code: CodeEmpty, Offset: -1, Err: errors.New("missing years field")})
+	}
+	if next() && hasYears && o.features&FeatureYears != 0 {
+		var ferr error
+		e.y, ferr = o.parseYears(y)
+		check(FieldYears, ferr)
+	}
+	if o.maxYear < maxYear {
+		// Keep "*" as if there were no years field, so that it does not
+		// render as the range of years of the dialect.
+		if y == "*" {
+			e.y = years{}
 		}
-		if e.dom&domAllowed == 0 {
-			return e, &parseError{fieldDaysOfMonth, errors.New("impossible day of month")}
+		if e.y.isEmpty() {
+			e.lastYear = o.maxYear
 		}
 	}
 
+	// Detect impossible combinations of month/day pairs, e.g., February 30th
+	// or "L-30" in April, unless either field is invalid.
+	e.daysOr = o.daysOr && !o.isWildcard(dom) && !o.isWildcard(dow)
+	e.wildcardDays = strings.HasPrefix(dom, "*") || strings.HasPrefix(dow, "*")
+	datesValid, dowValid := next(), true
+	for _, perr := range perrs {
+		datesValid = datesValid && perr.Field != FieldDaysOfMonth && perr.Field != FieldMonths
+		dowValid = dowValid && perr.Field != FieldDaysOfWeek
+	}
+	switch {
+	case !datesValid:
+	case e.daylessMonths() == e.mon:
+		check(FieldDaysOfMonth, &ParseError{
+			Field: FieldDaysOfMonth, Code: CodeImpossibleDate, Len: len(dom),
+			Err: errors.New("impossible day of month"),
+		})
+	case !dowValid:
+	case !e.hasDays():
+		// E.g., the second Monday is never the 1st, so Next and Prev would
+		// search forever.
+		check(FieldDaysOfWeek, &ParseError{
+			Field: FieldDaysOfWeek, Code: CodeImpossibleDate, Len: len(dow),
+			Err: errors.New("days of week never match the days of month"),
+		})
+	}
+	if err != nil && o.allErrors {
+		return e, perrs
+	}
+	if err != nil {
+		return e, err
+	}
+
 	e.expr = expr
+	e.seconds = o.seconds
+	e.random = o.chosen
+	e.dst = o.dst
 
 	return e, nil
 }
 
+var macros = [...]struct{ name, expr string }{
+	{"@yearly", "0 0 1 1 *"},
+	{"@annually", "0 0 1 1 *"},
+	{"@monthly", "0 0 1 * *"},
+	{"@weekly", "0 0 * * 0"},
+	{"@daily", "0 0 * * *"},
+	{"@midnight", "0 0 * * *"},
+	{"@hourly", "0 * * * *"},
+}
+
+// expandMacro returns the five-field expression equivalent to the given
+// predefined macro, e.g., "0 0 * * *" for "@daily".
+func expandMacro(name string) (expr string, ok bool) {
+	for _, m := range macros {
+		if strings.EqualFold(name, m.name) {
+			return m.expr, true
+		}
+	}
+	return "", false
+}
+
+// cutPrefixFold is like strings.CutPrefix, but matches prefix
+// case-insensitively.
+func cutPrefixFold(s, prefix string) (after string, found bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
 func splitFields(expr string) (m, h, dom, mon, dow string) {
 	m, expr, _ = strings.Cut(expr, " ")
 	h, expr, _ = strings.Cut(expr, " ")
@@ -104,92 +744,455 @@ func splitFields(expr string) (m, h, dom, mon, dow string) {
 	return
 }
 
+func (o *options) parseField(groups string, typ Field, min, max int) (field uint64, err error) {
+	err = parseGroups(groups, typ, func(group string) error {
+		from, to, step, err := o.parseGroup(typ, group, min, max)
+		if err == nil {
+			field |= rangeBits(from, to, step, min, max)
+		}
+		return err
+	})
+	return field, err
+}
+
 /*
-parseField implements the following BNF:
+parseDaysOfMonth extends the BNF of parseGroups with the following groups,
+where a trailing 'W' stands for the weekday nearest to the given day:
+
+	group      ::= 'L' ( '-' number )? 'W'? | number 'W'
+*/
+func (o *options) parseDaysOfMonth(groups string) (dom, domL, domW, domLW uint32, err error) {
+	if o.quartz && strings.Contains(groups, ",") && strings.ContainsAny(groups, "LlWw") {
+		return dom, domL, domW, domLW, &ParseError{
+			Field: FieldDaysOfMonth, Code: CodeModifierInList, Len: len(groups),
+			Err: errors.New("L or W found with other days"),
+		}
+	}
+	err = parseGroups(groups, FieldDaysOfMonth, func(group string) error {
+		if o.features&FeatureModifiers == 0 && strings.ContainsAny(group, "LlWw") {
+			return &ParseError{
+				Field: FieldDaysOfMonth, Code: CodeNotSupported, Len: len(group),
+				Err: fmt.Errorf("modifier in %q not supported", group),
+			}
+		}
+
+		weekday := len(group) > 0 && toLower(group[len(group)-1]) == 'w'
+		if weekday {
+			group = group[:len(group)-1]
+		}
+
+		if group == "" || toLower(group[0]) != 'l' {
+			if weekday {
+				d, err := parseNumber(FieldDaysOfMonth, group, 1, 31)
+				if err == nil {
+					domW |= uint32(1) << d
+				}
+				return err
+			}
+			from, to, step, err := o.parseGroup(FieldDaysOfMonth, group, 1, 31)
+			if err == nil {
+				dom |= uint32(rangeBits(from, to, step, 1, 31))
+			}
+			return err
+		}
+
+		offset := 0
+		if rest := group[1:]; rest == "-" {
+			return &ParseError{Field: FieldDaysOfMonth, Code: CodeSyntax, Offset: 1, Len: 1, Err: errors.New("trailing dash found")}
+		} else if strings.HasPrefix(rest, "-") {
+			var err error
+			if offset, err = parseNumber(FieldDaysOfMonth, rest[1:], 0, 30); err != nil {
+				return shiftError(err, len("L-"))
+			}
+		} else if rest != "" {
+			return &ParseError{
+				Field: FieldDaysOfMonth, Code: CodeSyntax, Offset: 1, Len: len(rest),
+				Err: fmt.Errorf("unexpected %q after L", rest),
+			}
+		}
+		if weekday {
+			domLW |= uint32(1) << offset
+		} else {
+			domL |= uint32(1) << offset
+		}
+		return nil
+	})
+	return dom, domL, domW, domLW, err
+}
+
+/*
+parseDaysOfWeek extends the BNF of parseGroups with the following groups,
+where '#' stands for the nth given day of week of the month, and 'L' for the
+last one:
+
+	group      ::= number '#' number | number 'L'
+
+Days of week range from 0 to 7, where both 0 and 7 stand for Sunday, or from 1
+(Sunday) to 7 (Saturday) in the Quartz syntax.
+*/
+func (o *options) parseDaysOfWeek(groups string) (dow uint8, dowN [5]uint8, dowL uint8, err error) {
+	min, max := 0, 7
+	if o.features&FeatureSunday7 == 0 {
+		max = 6
+	}
+	if o.quartz {
+		min, max = 1, 7
+		if strings.Contains(groups, ",") && strings.ContainsAny(groups, "Ll#") {
+			return dow, dowN, dowL, &ParseError{
+				Field: FieldDaysOfWeek, Code: CodeModifierInList, Len: len(groups),
+				Err: errors.New("L or # found with other days"),
+			}
+		}
+	}
+	err = parseGroups(groups, FieldDaysOfWeek, func(group string) error {
+		if o.features&FeatureModifiers == 0 && (strings.Contains(group, "#") || len(group) > 1 && toLower(group[len(group)-1]) == 'l') {
+			return &ParseError{
+				Field: FieldDaysOfWeek, Code: CodeNotSupported, Len: len(group),
+				Err: fmt.Errorf("modifier in %q not supported", group),
+			}
+		}
+
+		if d, n, found := strings.Cut(group, "#"); found {
+			wd, err := o.parseAliasOrNumber(FieldDaysOfWeek, d, min, max)
+			if err != nil {
+				return err
+			}
+			nth, err := parseNumber(FieldDaysOfWeek, n, 1, 5)
+			if err == nil {
+				dowN[nth-1] |= 1 << ((wd - min) % 7)
+			}
+			return shiftError(err, len(d)+1)
+		}
+
+		if len(group) > 1 && toLower(group[len(group)-1]) == 'l' {
+			wd, err := o.parseAliasOrNumber(FieldDaysOfWeek, group[:len(group)-1], min, max)
+			if err == nil {
+				dowL |= 1 << ((wd - min) % 7)
+			}
+			return err
+		}
+
+		if o.quartz {
+			if group == "L" || group == "l" {
+				dow |= 1 << time.Saturday
+				return nil
+			}
+			from, to, step, err := o.parseGroup(FieldDaysOfWeek, group, min, max)
+			if err == nil {
+				dow |= uint8(rangeBits(from, to, step, min, max) >> 1)
+			}
+			return err
+		}
+
+		// Both 0 and 7 stand for Sunday, so ranges wrap around from 6 to 0,
+		// e.g., "sat-mon" is 6-1, and "7-1" is 0-1.
+		from, to, step, err := o.parseGroup(FieldDaysOfWeek, group, min, max)
+		if err != nil {
+			return err
+		}
+		if from > to {
+			from %= 7
+		}
+		last := max
+		if from > to {
+			last = 6
+		}
+		bits := rangeBits(from, to, step, min, last)
+		dow |= uint8(bits&(1<<7-1) | bits>>7)
+		return nil
+	})
+	return dow, dowN, dowL, err
+}
+
+// isWildcard reports whether the days field groups is unrestricted for
+// WithDaysOr, that is, whether it starts with "*", or has a "*" or "?" group
+// under DialectKubernetes.
+func (o *options) isWildcard(groups string) bool {
+	if !o.kubernetes {
+		return strings.HasPrefix(groups, "*")
+	}
+	for _, group := range strings.Split(groups, ",") {
+		if group == "*" || group == "?" {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *options) parseYears(groups string) (y years, err error) {
+	err = parseGroups(groups, FieldYears, func(group string) error {
+		from, to, step, err := o.parseGroup(FieldYears, group, minYear, o.maxYear)
+		if err == nil {
+			forEachInRange(from, to, step, minYear, o.maxYear, y.set)
+		}
+		return err
+	})
+	return y, err
+}
+
+/*
+parseGroups implements the following BNF, calling fn for each group found:
 
 	groups     ::= group ( ',' group )*
-	group      ::= '*' | rangeOrNum ( '/' step )?
+	group      ::= ( '*' | hash | rangeOrNum ) ( '/' step )? | random
+	hash       ::= 'H' ( '(' number '-' number ')' )?
+	random     ::= number? '~' number?
 	rangeOrNum ::= number ( '-' number )?
 	step       ::= number
 	number     ::= digit+
 	digit      ::= '0'..'9'
+
+A range whose start is greater than its end wraps around the end of the field,
+e.g., hours 22-2 are 22, 23, 0, 1 and 2.
 */
-func parseField(groups string, typ fieldType, min, max int) (field uint64, err error) {
+func parseGroups(groups string, typ Field, fn func(group string) error) error {
 	if groups == "" {
-		return field, &parseError{typ: typ, err: errors.New("field is empty")}
+		return &ParseError{Field: typ, Code: CodeEmpty, Err: errors.New("field is empty")}
 	}
-	for groups != "" {
-		group, rest, commaFound := strings.Cut(groups, ",")
+	for off := 0; off < len(groups); {
+		group, rest, commaFound := strings.Cut(groups[off:], ",")
 		if commaFound && rest == "" {
-			return field, &parseError{typ, errors.New("trailing comma found")}
+			return &ParseError{Field: typ, Code: CodeSyntax, Offset: len(groups) - 1, Len: 1, Err: errors.New("trailing comma found")}
 		}
-		groups = rest
 
-		if from, to, step, err := parseGroup(typ, group, min, max); err != nil {
-			return field, err
-		} else if step == 1 {
-			field |= uint64(1)<<(to+1) - uint64(1)<<from
-		} else {
-			for i := from; i <= to; i += step {
-				field |= uint64(1) << i
-			}
+		if err := fn(group); err != nil {
+			return shiftError(err, off)
 		}
+		off += len(group) + 1
+	}
+	return nil
+}
+
+// rangeBits returns a bitfield with the bits from, from+step, ..., up to to
+// set. If from > to, the range wraps around from max to min.
+func rangeBits(from, to, step, min, max int) (field uint64) {
+	if from <= to && step == 1 {
+		return uint64(1)<<(to+1) - uint64(1)<<from
 	}
-	return field, nil
+	forEachInRange(from, to, step, min, max, func(i int) {
+		field |= uint64(1) << i
+	})
+	return field
 }
 
-func parseGroup(typ fieldType, expr string, min, max int) (from, to, step int, err error) {
-	if expr == "*" {
-		return min, max, 1, nil
+// forEachInRange calls fn with from, from+step, ..., up to to. If from > to,
+// the range wraps around from max to min, e.g., hours 22-2/2 are 22, 0 and 2.
+func forEachInRange(from, to, step, min, max int, fn func(int)) {
+	size := max - min + 1
+	if from > to {
+		to += size
+	}
+	for i := from; i <= to; i += step {
+		if i > max {
+			fn(i - size)
+		} else {
+			fn(i)
+		}
 	}
+}
 
+func (o *options) parseGroup(typ Field, expr string, min, max int) (from, to, step int, err error) {
 	rangeOrNum, rangeStep, foundStep := strings.Cut(expr, "/")
-	rangeFrom, rangeTo, foundTo := strings.Cut(rangeOrNum, "-")
 	if foundStep && rangeStep == "" {
-		return from, to, step, &parseError{typ, errors.New("trailing slash found")}
+		return from, to, step, &ParseError{Field: typ, Code: CodeSyntax, Offset: len(expr) - 1, Len: 1, Err: errors.New("trailing slash found")}
+	}
+	stepOff := len(rangeOrNum) + 1
+	if rangeOrNum == "*" || o.kubernetes && rangeOrNum == "?" {
+		from, to, step = min, max, 1
+		if foundStep {
+			step, err = parseNumber(typ, rangeStep, 1, max-min+1)
+		}
+		return from, to, step, shiftError(err, stepOff)
+	}
+	if rangeOrNum != "" && toLower(rangeOrNum[0]) == 'h' {
+		return o.parseHash(typ, rangeOrNum[1:], rangeStep, foundStep, min, max)
+	}
+	if randFrom, randTo, found := strings.Cut(rangeOrNum, "~"); found {
+		if foundStep {
+			return from, to, step, &ParseError{
+				Field: typ, Code: CodeSyntax, Offset: len(rangeOrNum), Len: len(rangeStep) + 1,
+				Err: errors.New("step found after random range"),
+			}
+		}
+		from, err = o.parseRandom(typ, expr, randFrom, randTo, min, max)
+		return from, from, 1, err
 	}
+
+	rangeFrom, rangeTo, foundTo := strings.Cut(rangeOrNum, "-")
 	if foundTo && rangeTo == "" {
-		return from, to, step, &parseError{typ, errors.New("trailing dash found")}
+		return from, to, step, &ParseError{Field: typ, Code: CodeSyntax, Offset: len(rangeOrNum) - 1, Len: 1, Err: errors.New("trailing dash found")}
 	}
 
-	from, err = parseAliasOrNumber(typ, rangeFrom, min, max)
+	from, err = o.parseAliasOrNumber(typ, rangeFrom, min, max)
 
 	if rangeTo == "" && rangeStep == "" {
 		to = from
 	} else if rangeTo == "" {
 		to = max
 	} else if err == nil {
-		to, err = parseAliasOrNumber(typ, rangeTo, from, max)
+		to, err = o.parseAliasOrNumber(typ, rangeTo, min, max)
+		err = shiftError(err, len(rangeFrom)+1)
 	}
 
 	if rangeStep == "" {
 		step = 1
 	} else if err == nil {
 		step, err = parseNumber(typ, rangeStep, 1, max-min+1)
+		err = shiftError(err, stepOff)
+	}
+	if err == nil && o.kubernetes && from > to {
+		// robfig/cron rejects ranges that wrap around.
+		perr := &ParseError{
+			Field: typ, Code: CodeReversedRange, Len: len(rangeOrNum),
+			Err: fmt.Errorf("reversed range %q found", rangeOrNum),
+		}
+		if !foundStep {
+			perr.Suggestion = fmt.Sprintf("ranges cannot wrap around; did you mean \"%d-%d,%d-%d\"?", from, max, min, to)
+		}
+		return from, to, step, perr
 	}
 
 	return from, to, step, err
 }
 
-func parseAliasOrNumber(typ fieldType, s string, min, max int) (n int, err error) {
+// parseHash parses a group starting with "H", given what follows the "H" up
+// to the step, if any. See WithHash.
+func (o *options) parseHash(typ Field, hashRange, rangeStep string, foundStep bool, min, max int) (from, to, step int, err error) {
+	if !o.hash {
+		return from, to, step, &ParseError{Field: typ, Code: CodeNotSupported, Len: 1, Err: errors.New("H found, but no hash key given")}
+	}
+
+	lo, hi := min, max
 	switch typ {
-	case fieldMonths:
+	case FieldDaysOfMonth:
+		hi = 28 // Match every month.
+	case FieldDaysOfWeek:
+		hi = min + 6 // Don't favor Sunday, which may be both 0 and 7.
+	}
+	if hashRange != "" {
+		r := strings.TrimSuffix(strings.TrimPrefix(hashRange, "("), ")")
+		rangeFrom, rangeTo, foundTo := strings.Cut(r, "-")
+		if len(r) != len(hashRange)-2 || !foundTo {
+			return from, to, step, &ParseError{
+				Field: typ, Code: CodeSyntax, Offset: 1, Len: len(hashRange),
+				Err: fmt.Errorf("malformed H range %q found", hashRange),
+			}
+		}
+		if lo, err = o.parseAliasOrNumber(typ, rangeFrom, min, max); err != nil {
+			return from, to, step, shiftError(err, len("H("))
+		}
+		if hi, err = o.parseAliasOrNumber(typ, rangeTo, min, max); err != nil {
+			return from, to, step, shiftError(err, len("H(")+len(rangeFrom)+1)
+		}
+		if hi < lo {
+			return from, to, step, &ParseError{
+				Field: typ, Code: CodeReversedRange, Offset: 1, Len: len(hashRange),
+				Err:        fmt.Errorf("reversed H range %q found", hashRange),
+				Suggestion: fmt.Sprintf("did you mean \"H(%s-%s)\"?", rangeTo, rangeFrom),
+			}
+		}
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(o.hashKey))
+	h.Write([]byte{byte(typ)})
+	sum := h.Sum64()
+	// FNV spreads similar keys poorly over small ranges, so mix the bits
+	// further with the MurmurHash3 finalizer.
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	sum *= 0xc4ceb9fe1a85ec53
+	sum ^= sum >> 33
+
+	if !foundStep {
+		from = lo + int(sum%uint64(hi-lo+1))
+		return from, from, 1, nil
+	}
+	if step, err = parseNumber(typ, rangeStep, 1, hi-lo+1); err != nil {
+		return from, to, step, shiftError(err, len("H")+len(hashRange)+1)
+	}
+	return lo + int(sum%uint64(step)), hi, step, nil
+}
+
+// parseRandom parses a "~" group, given what surrounds the "~". See
+// WithRandom.
+func (o *options) parseRandom(typ Field, group, randFrom, randTo string, min, max int) (n int, err error) {
+	if !o.random {
+		return n, &ParseError{Field: typ, Code: CodeNotSupported, Offset: len(randFrom), Len: 1, Err: errors.New("~ found, but no random source given")}
+	}
+
+	lo, hi := min, max
+	if typ == FieldDaysOfWeek {
+		hi = min + 6 // Don't favor Sunday, which may be both 0 and 7.
+	}
+	if randFrom != "" {
+		if lo, err = o.parseAliasOrNumber(typ, randFrom, min, max); err != nil {
+			return n, err
+		}
+	}
+	if randTo != "" {
+		if hi, err = o.parseAliasOrNumber(typ, randTo, min, max); err != nil {
+			return n, shiftError(err, len(randFrom)+1)
+		}
+		if hi < lo {
+			return n, &ParseError{
+				Field: typ, Code: CodeReversedRange, Len: len(group),
+				Err:        fmt.Errorf("reversed random range %q found", group),
+				Suggestion: fmt.Sprintf("did you mean %q?", randTo+"~"+randFrom),
+			}
+		}
+	} else if hi < lo {
+		hi = max
+	}
+
+	if o.rand != nil {
+		n = lo + o.rand.Intn(hi-lo+1)
+	} else {
+		n = lo + rand.Intn(hi-lo+1)
+	}
+	o.chosen = append(o.chosen, RandomValue{Field: typ, Group: group, Value: n})
+	return n, nil
+}
+
+func (o *options) parseAliasOrNumber(typ Field, s string, min, max int) (n int, err error) {
+	if (o.quartz || o.kubernetes) && len(s) > 3 {
+		// Quartz and robfig/cron only accept three-letter names.
+		return parseNumber(typ, s, min, max)
+	}
+	switch typ {
+	case FieldMonths:
 		if n, ok := monFromName(s); ok {
 			return n, nil
 		}
-	case fieldDaysOfWeek:
-		if n, ok := dowFromName(s); ok {
+	case FieldDaysOfWeek:
+		if n, ok := dowFromName(s); ok && o.quartz {
+			return n + 1, nil
+		} else if ok {
 			return n, nil
 		}
 	}
 	return parseNumber(typ, s, min, max)
 }
 
+var monNames = [...]string{
+	"january", "february", "march", "april", "may", "june",
+	"july", "august", "september", "october", "november", "december",
+}
+
+// monFromName returns the month for either its full English name or its
+// first three letters, case-insensitively.
 func monFromName(name string) (n int, ok bool) {
-	if len(name) != 3 {
+	if len(name) < 3 {
 		return 0, false
 	}
+	if n, ok = monFromAbbr(name[:3]); ok && len(name) > 3 {
+		ok = strings.EqualFold(name, monNames[n-1])
+	}
+	return n, ok
+}
 
+func monFromAbbr(name string) (n int, ok bool) {
 	switch n0, n1, n2 := toLower(name[0]), toLower(name[1]), toLower(name[2]); {
 	case n0 == 'a' && n1 == 'p' && n2 == 'r': // apr
 		return 4, true
@@ -220,11 +1223,23 @@ func monFromName(name string) (n int, ok bool) {
 	return 0, false
 }
 
-func dowFromName(name string) (int, bool) {
-	if len(name) != 3 {
+var dowNames = [...]string{
+	"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday",
+}
+
+// dowFromName returns the day of week for either its full English name or
+// its first three letters, case-insensitively.
+func dowFromName(name string) (n int, ok bool) {
+	if len(name) < 3 {
 		return 0, false
 	}
+	if n, ok = dowFromAbbr(name[:3]); ok && len(name) > 3 {
+		ok = strings.EqualFold(name, dowNames[n])
+	}
+	return n, ok
+}
 
+func dowFromAbbr(name string) (int, bool) {
 	switch n0, n1, n2 := toLower(name[0]), toLower(name[1]), toLower(name[2]); {
 	case n0 == 'f' && n1 == 'r' && n2 == 'i': // fri
 		return 5, true
@@ -252,125 +1267,517 @@ func toLower(b byte) byte {
 	return b
 }
 
-func parseNumber(typ fieldType, s string, min, max int) (n int, err error) {
+func parseNumber(typ Field, s string, min, max int) (n int, err error) {
 	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
-		return 0, &parseError{typ, errors.New("leading sign found")}
+		return 0, &ParseError{Field: typ, Code: CodeSyntax, Len: len(s), Err: errors.New("leading sign found")}
 	}
 	if n, err = strconv.Atoi(s); err != nil {
-		return n, &parseError{typ, err}
+		return n, &ParseError{Field: typ, Code: CodeSyntax, Len: len(s), Err: err}
 	}
 	if n < min || n > max {
-		return n, &parseError{
-			typ, fmt.Errorf("value out of range [%d, %d] found", min, max)}
+		return n, &ParseError{
+			Field: typ, Code: CodeOutOfRange, Len: len(s), Min: min, Max: max,
+			Err: fmt.Errorf("value out of range [%d, %d] found", min, max),
+		}
 	}
 	return n, nil
 }
 
-type parseError struct {
-	typ fieldType
-	err error
+// Prev returns the latest activation time of e before from, or the zero Time
+// if there is none, which can only happen if e has a years field. The result
+// is in the location of e, if any, or else in that of from. Activation times
+// made ambiguous by daylight saving time transitions are handled according to
+// the DSTPolicy of e.
+func (e *Expr) Prev(from time.Time) time.Time {
+	if e.loc != nil {
+		from = from.In(e.loc)
+	}
+	if e.every != 0 {
+		// Find the latest multiple of e.every before from.
+		n := floorDiv(from.Sub(e.anchor)-1, e.every)
+		return e.anchor.Add(time.Duration(n) * e.every).In(from.Location())
+	}
+
+	// Search wall clock times backwards from that of from, and map each
+	// match to the instants at which it occurs. Around a transition, the
+	// latest activation may come from a wall clock time later than that of
+	// from, or earlier than the first match, so those are checked apart.
+	loc := from.Location()
+	p := int64(e.precision() / time.Second)
+	upper := from.Truncate(e.precision()).Unix()
+	start := upper + offset(upper, loc) - p
+
+	var best int64
+	var found bool
+	consider := func(t int64) {
+		if t < upper && (!found || t > best) {
+			best, found = t, true
+		}
+	}
+
+	if tr, ok := transitionNear(upper, loc); ok && tr.o1 > tr.o2 {
+		d := tr.o1 - tr.o2
+		if upper >= tr.t && upper <= tr.t+d {
+			// upper is in the second pass of an overlap, so the first pass of
+			// every wall clock time in it precedes upper.
+			start = tr.t + tr.o1 - p
+			if e.dst&TwiceInOverlap != 0 {
+				to := tr.t + tr.o2 + min64(d, upper-tr.t) - p
+				if w, ok := e.prevWall(to); ok && w >= tr.t+tr.o2 {
+					consider(w - tr.o2)
+				}
+			}
+		}
+	}
+
+	for w, ok := e.prevWall(start); ok && !found; w, ok = e.prevWall(w - p) {
+		ts, n, tr := wallInstants(w, loc)
+		switch {
+		case n == 0 && e.dst&ShiftGap != 0:
+			if t := w - tr.o1; t < upper {
+				consider(t)
+			} else {
+				w = upper + tr.o1
+			}
+		case n == 0:
+			// Skip the rest of the gap.
+			w = tr.t + tr.o1
+		case n == 2 && e.dst&TwiceInOverlap != 0 && ts[1] < upper:
+			consider(ts[1])
+		default:
+			consider(ts[0])
+		}
+	}
+
+	if found && e.dst&ShiftGap != 0 {
+		// Wall clock times in a gap before that of best may be shifted past
+		// best.
+		if tr, ok := transitionNear(best, loc); ok && tr.o2 > tr.o1 && best >= tr.t && best < tr.t+tr.o2-tr.o1 {
+			to := min64(tr.t+tr.o2, upper+tr.o1) - p
+			if w, ok := e.prevWall(to); ok && w >= tr.t+tr.o1 {
+				consider(w - tr.o1)
+			}
+		}
+	}
+
+	if !found {
+		return time.Time{}
+	}
+	return time.Unix(best, 0).In(loc)
 }
 
-func (e *parseError) Error() string {
-	return fmt.Sprintf("field %q: %v", e.typ, e.err)
+// Next returns the earliest activation time of e after from, or the zero
+// Time if there is none, which can only happen if e has a years field. The
+// result is in the location of e, if any, or else in that of from. Activation
+// times made ambiguous by daylight saving time transitions are handled
+// according to the DSTPolicy of e.
+func (e *Expr) Next(from time.Time) time.Time {
+	if e.loc != nil {
+		from = from.In(e.loc)
+	}
+	if e.every != 0 {
+		// Find the earliest multiple of e.every after from.
+		n := floorDiv(from.Sub(e.anchor), e.every) + 1
+		return e.anchor.Add(time.Duration(n) * e.every).In(from.Location())
+	}
+
+	// Search wall clock times forwards from that of from, and map each match
+	// to the instants at which it occurs. Around a transition, the earliest
+	// activation may come from a wall clock time earlier than that of from,
+	// so those are checked apart.
+	loc := from.Location()
+	p := int64(e.precision() / time.Second)
+	lower := from.Truncate(e.precision()).Unix()
+	start := lower + offset(lower, loc) + p
+
+	var best int64
+	var found bool
+	consider := func(t int64) {
+		if t > lower && (!found || t < best) {
+			best, found = t, true
+		}
+	}
+
+	if tr, ok := transitionNear(lower, loc); ok {
+		switch d := tr.o1 - tr.o2; {
+		case d > 0 && lower >= tr.t-d && lower < tr.t+d:
+			if lower >= tr.t {
+				// lower is in the second pass of an overlap, so the first pass
+				// of every wall clock time in it precedes lower.
+				start = tr.t + tr.o1
+			}
+			if e.dst&TwiceInOverlap != 0 {
+				from := max64(tr.t+tr.o2, lower+tr.o2+p)
+				if w, ok := e.nextWall(from); ok && w < tr.t+tr.o1 {
+					consider(w - tr.o2)
+				}
+			}
+		case d < 0 && lower >= tr.t && lower < tr.t-d && e.dst&ShiftGap != 0:
+			// Wall clock times in the gap may be shifted past lower.
+			if w, ok := e.nextWall(lower + tr.o1 + p); ok && w < tr.t+tr.o2 {
+				consider(w - tr.o1)
+			}
+		}
+	}
+
+	for w, ok := e.nextWall(start); ok; w, ok = e.nextWall(w + p) {
+		ts, n, tr := wallInstants(w, loc)
+		if n == 0 {
+			if e.dst&ShiftGap != 0 {
+				consider(w - tr.o1)
+			}
+			// Skip the rest of the gap.
+			w = tr.t + tr.o2 - p
+			continue
+		}
+		if ts[0] > lower {
+			consider(ts[0])
+			break
+		}
+	}
+
+	if !found {
+		return time.Time{}
+	}
+	return time.Unix(best, 0).In(loc)
 }
 
-func (e *Expr) Prev(from time.Time) time.Time {
-	t := from.Truncate(time.Minute).Add(-time.Minute)
-	m, h, dom, mon, dow := e.m, e.h, e.dom, e.mon, e.dow
+// prevWall returns the latest wall clock time matching e at or before w, or
+// false if there is none. Wall clock times are given as seconds since the
+// Unix epoch in UTC, which has no daylight saving time transitions.
+func (e *Expr) prevWall(w int64) (int64, bool) {
+	t := time.Unix(w, 0).UTC()
+	s, m, h, mon := e.s, e.m, e.h, e.mon
 
 	var dateY int
 	var dateMon time.Month
 	var dateDom int
-	var dateDow time.Weekday
-	var dateH, dateM int
+	var dateH, dateM, dateS int
 day:
 	for {
 		dateY, dateMon, dateDom = t.Date()
-		dateDow = t.Weekday()
 		switch {
+		case e.lastYear != 0 && dateY > e.lastYear:
+			dateY, dateMon, dateDom = e.lastYear, time.December, 31
+		case !e.y.has(dateY):
+			if dateY = e.y.prev(dateY); dateY < minYear {
+				return 0, false
+			}
+			dateMon, dateDom = time.December, 31
 		case mon&(1<<dateMon) == 0:
 			dateMon = prev(dateMon, time.January, mon) + 1
 			dateDom = 0
-		case dom&(1<<dateDom) == 0:
-			dateDom = prev(dateDom, 1, dom)
-		case dow&(1<<dateDow) == 0:
-			dowPrev := prev(dateDow, time.Sunday, dow)
-			dateDom -= int(dateDow - dowPrev)
 		default:
-			break day
+			days := e.days(dateY, dateMon)
+			if days&(1<<dateDom) != 0 {
+				break day
+			}
+			dateDom = prev(dateDom, 1, days)
 		}
-		t = time.Date(dateY, dateMon, dateDom, 23, 59, 0, 0, t.Location())
+		t = time.Date(dateY, dateMon, dateDom, 23, 59, 59, 0, time.UTC)
 	}
 	doy := t.YearDay()
 hour:
 	for {
-		dateH, dateM, _ = t.Clock()
+		dateH, dateM, dateS = t.Clock()
 		switch {
 		case h&(1<<dateH) == 0:
 			dateH = prev(dateH, 0, h) + 1
-			dateM = -1
+			dateM, dateS = -1, 59
 		case m&(uint64(1)<<dateM) == 0:
 			dateM = prev(dateM, 0, m)
+			dateS = 59
+		case s&(uint64(1)<<dateS) == 0:
+			dateS = prev(dateS, 0, s)
 		default:
 			break hour
 		}
-		t = time.Date(dateY, dateMon, dateDom, dateH, dateM, 0, 0, t.Location())
+		t = time.Date(dateY, dateMon, dateDom, dateH, dateM, dateS, 0, time.UTC)
 		if t.YearDay() != doy {
 			// We hit a different day.
 			goto day
 		}
 	}
-	return t
+	return t.Unix(), true
 }
 
-func (e *Expr) Next(from time.Time) time.Time {
-	t := from.Truncate(time.Minute).Add(time.Minute)
-	m, h, dom, mon, dow := e.m, e.h, e.dom, e.mon, e.dow
+// nextWall returns the earliest wall clock time matching e at or after w, or
+// false if there is none. Wall clock times are given as in prevWall.
+func (e *Expr) nextWall(w int64) (int64, bool) {
+	t := time.Unix(w, 0).UTC()
+	s, m, h, mon := e.s, e.m, e.h, e.mon
 
 	var dateY int
 	var dateMon time.Month
 	var dateDom int
-	var dateDow time.Weekday
-	var dateH, dateM int
+	var dateH, dateM, dateS int
 day:
 	for {
 		dateY, dateMon, dateDom = t.Date()
-		dateDow = t.Weekday()
 		switch {
+		case e.lastYear != 0 && dateY > e.lastYear:
+			return 0, false
+		case !e.y.has(dateY):
+			if dateY = e.y.next(dateY); dateY > maxYear {
+				return 0, false
+			}
+			dateMon, dateDom = time.January, 1
 		case mon&(1<<dateMon) == 0:
 			dateMon = next(dateMon, time.December, mon)
 			dateDom = 1
-		case dom&(1<<dateDom) == 0:
-			dateDom = next(dateDom, maxDomForMon(dateY, dateMon), dom)
-		case dow&(1<<dateDow) == 0:
-			dowNext := next(dateDow, time.Saturday, dow)
-			dateDom += int(dowNext - dateDow)
 		default:
-			break day
+			days := e.days(dateY, dateMon)
+			if days&(1<<dateDom) != 0 {
+				break day
+			}
+			dateDom = next(dateDom, maxDomForMon(dateY, dateMon), days)
 		}
-		t = time.Date(dateY, dateMon, dateDom, 0, 0, 0, 0, t.Location())
+		t = time.Date(dateY, dateMon, dateDom, 0, 0, 0, 0, time.UTC)
 	}
 	doy := t.YearDay()
 hour:
 	for {
-		dateH, dateM, _ = t.Clock()
+		dateH, dateM, dateS = t.Clock()
 		switch {
 		case h&(1<<dateH) == 0:
 			dateH = next(dateH, 23, h)
-			dateM = 0
+			dateM, dateS = 0, 0
 		case m&(uint64(1)<<dateM) == 0:
 			dateM = next(dateM, 59, m)
+			dateS = 0
+		case s&(uint64(1)<<dateS) == 0:
+			dateS = next(dateS, 59, s)
 		default:
 			break hour
 		}
-		t = time.Date(dateY, dateMon, dateDom, dateH, dateM, 0, 0, t.Location())
+		t = time.Date(dateY, dateMon, dateDom, dateH, dateM, dateS, 0, time.UTC)
 		if t.YearDay() != doy {
 			// We hit a different day.
 			goto day
 		}
 	}
-	return t
+	return t.Unix(), true
+}
+
+// A transition is a change in the UTC offset of a location, from o1 to o2
+// seconds east of UTC, at the instant t, in seconds since the Unix epoch.
+// Wall clock times from t+o1 to t+o2 are skipped if o1 < o2, and repeated if
+// o1 > o2.
+type transition struct {
+	t, o1, o2 int64
+}
+
+// transitionNear returns the transition of loc within a day of the instant t,
+// or false if there is none. It assumes there is at most one.
+func transitionNear(t int64, loc *time.Location) (tr transition, ok bool) {
+	const day = 24 * 60 * 60
+	if loc == time.UTC {
+		return tr, false
+	}
+	lo, hi := t-day, t+day
+	tr.o1, tr.o2 = offset(lo, loc), offset(hi, loc)
+	if tr.o1 == tr.o2 {
+		return tr, false
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if offset(mid, loc) == tr.o1 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	tr.t = hi
+	return tr, true
+}
+
+// wallInstants returns the instants at which the wall clock time w occurs in
+// loc, in increasing order: none if w is skipped by a transition, two if w is
+// repeated by a transition, and one otherwise. tr is the transition near w, if
+// any.
+func wallInstants(w int64, loc *time.Location) (ts [2]int64, n int, tr transition) {
+	tr, ok := transitionNear(w, loc)
+	if !ok {
+		ts[0] = w - offset(w, loc)
+		return ts, 1, tr
+	}
+	o1, o2 := tr.o1, tr.o2
+	switch {
+	case w < tr.t+min64(o1, o2):
+		ts[0], n = w-o1, 1
+	case w >= tr.t+max64(o1, o2):
+		ts[0], n = w-o2, 1
+	case o1 > o2:
+		ts[0], ts[1], n = w-o1, w-o2, 2
+	}
+	return ts, n, tr
+}
+
+// offset returns the UTC offset of loc at the instant t, in seconds east of
+// UTC.
+func offset(t int64, loc *time.Location) int64 {
+	if loc == time.UTC {
+		return 0
+	}
+	_, off := time.Unix(t, 0).In(loc).Zone()
+	return int64(off)
+}
+
+// days returns a bitfield of the days of the given month that match the days
+// of month and the days of week fields of e.
+func (e *Expr) days(y int, mon time.Month) uint32 {
+	return e.monthDays(maxDomForMon(y, mon), time.Date(y, mon, 1, 0, 0, 0, 0, time.UTC).Weekday())
+}
+
+// monthDays is like days, but takes the last day and the day of week of the
+// first day of the month, which are all that set its days apart.
+func (e *Expr) monthDays(last int, first time.Weekday) uint32 {
+	dom := e.dom
+	for l := e.domL; l != 0; l &= l - 1 {
+		if d := last - bits.TrailingZeros32(l); d >= 1 {
+			dom |= 1 << d
+		}
+	}
+	for w := e.domW; w != 0; w &= w - 1 {
+		if d := bits.TrailingZeros32(w); d <= last {
+			dom |= 1 << nearestWeekday(d, last, first)
+		}
+	}
+	for w := e.domLW; w != 0; w &= w - 1 {
+		if d := last - bits.TrailingZeros32(w); d >= 1 {
+			dom |= 1 << nearestWeekday(d, last, first)
+		}
+	}
+
+	if e.dow == allDow && !e.daysOr {
+		return dom
+	}
+	var dow uint32
+	for d := 1; d <= 7; d++ {
+		wd := (int(first) + d - 1) % 7
+		if e.dow&(1<<wd) != 0 {
+			for i := d; i <= last; i += 7 {
+				dow |= 1 << i
+			}
+		}
+		for n, dowN := range e.dowN {
+			if i := d + 7*n; dowN&(1<<wd) != 0 && i <= last {
+				dow |= 1 << i
+			}
+		}
+		if e.dowL&(1<<wd) != 0 {
+			dow |= 1 << (d + (last-d)/7*7)
+		}
+	}
+	if e.daysOr {
+		return dom | dow
+	}
+	return dom & dow
+}
+
+// nearestWeekday returns the weekday (Monday to Friday) nearest to the given
+// day of a month with the given last day and first day of week, without
+// crossing to another month.
+func nearestWeekday(d, last int, first time.Weekday) int {
+	switch time.Weekday((int(first) + d - 1) % 7) {
+	case time.Saturday:
+		if d == 1 {
+			return d + 2
+		}
+		return d - 1
+	case time.Sunday:
+		if d == last {
+			return d - 2
+		}
+		return d + 1
+	}
+	return d
+}
+
+// precision returns the smallest time step between two activations of e.
+func (e *Expr) precision() time.Duration {
+	if e.seconds {
+		return time.Second
+	}
+	return time.Minute
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// floorDiv returns a/b rounded towards negative infinity.
+func floorDiv(a, b time.Duration) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return int64(q)
+}
+
+// daylessMonths returns the months of e in which its days of month field
+// matches no day, regardless of the days of week field, e.g., February,
+// April, June, September and November for "31". February is assumed to have
+// 29 days.
+func (e *Expr) daylessMonths() (mon uint16) {
+	if e.daysOr {
+		return 0
+	}
+	for m := time.January; m <= time.December; m++ {
+		maxDays := maxDomForMon(2000, m)
+		domAllowed := uint32(1)<<(maxDays+1) - 1<<1
+		domLAllowed := uint32(1)<<maxDays - 1
+		if e.mon&(1<<m) != 0 && (e.dom|e.domW)&domAllowed == 0 && (e.domL|e.domLW)&domLAllowed == 0 {
+			mon |= 1 << m
+		}
+	}
+	return mon
+}
+
+const (
+	allDom = uint32(1<<32 - 1<<1) // 1-31
+	allDow = uint8(1<<7 - 1)      // 0-6
+)
+
+// domAll reports whether the days of month fields of e match every day, with
+// no L or W groups.
+func (e *Expr) domAll() bool {
+	return e.dom == allDom && e.domL|e.domW|e.domLW == 0
+}
+
+// dowAll reports whether the days of week fields of e match every day, with
+// no # or L groups.
+func (e *Expr) dowAll() bool {
+	return e.dow == allDow && e.dowN == [5]uint8{} && e.dowL == 0
+}
+
+// hasDays reports whether the days fields of e match any day of its months in
+// some year. Every month starts on every day of week, and February has both 28
+// and 29 days, within the 400 years after which the calendar repeats itself.
+func (e *Expr) hasDays() bool {
+	for m := time.January; m <= time.December; m++ {
+		if e.mon&(1<<m) == 0 {
+			continue
+		}
+		for _, last := range [...]int{maxDomForMon(2000, m), maxDomForMon(2001, m)} {
+			for first := time.Sunday; first <= time.Saturday; first++ {
+				if e.monthDays(last, first) != 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
 }
 
 func maxDomForMon(y int, mon time.Month) int {
@@ -388,6 +1795,71 @@ func maxDomForMon(y int, mon time.Month) int {
 	}
 }
 
+const (
+	minYear = 1970
+	maxYear = 2199
+)
+
+// years is a bitfield of the years between minYear and maxYear. An empty
+// bitfield matches any year, including years out of that range.
+type years [(maxYear - minYear + 64) / 64]uint64
+
+func (y *years) set(year int) {
+	i := year - minYear
+	y[i/64] |= uint64(1) << (i % 64)
+}
+
+func (y *years) isEmpty() bool {
+	return *y == years{}
+}
+
+// isFull reports whether y has every year between minYear and maxYear.
+func (y *years) isFull() bool {
+	for year := minYear; year <= maxYear; year++ {
+		if y.isEmpty() || !y.has(year) {
+			return false
+		}
+	}
+	return true
+}
+
+// has reports whether year matches y.
+func (y *years) has(year int) bool {
+	if y.isEmpty() {
+		return true
+	}
+	i := year - minYear
+	return i >= 0 && year <= maxYear && y[i/64]&(uint64(1)<<(i%64)) != 0
+}
+
+// prev returns the latest year in y before year, or minYear-1 if there is
+// none.
+func (y *years) prev(year int) int {
+	if year > maxYear+1 {
+		year = maxYear + 1
+	}
+	for i := year - 1 - minYear; i >= 0; i-- {
+		if y[i/64]&(uint64(1)<<(i%64)) != 0 {
+			return minYear + i
+		}
+	}
+	return minYear - 1
+}
+
+// next returns the earliest year in y after year, or maxYear+1 if there is
+// none.
+func (y *years) next(year int) int {
+	if year < minYear-1 {
+		year = minYear - 1
+	}
+	for i := year + 1 - minYear; i <= maxYear-minYear; i++ {
+		if y[i/64]&(uint64(1)<<(i%64)) != 0 {
+			return minYear + i
+		}
+	}
+	return maxYear + 1
+}
+
 type timeUnit interface {
 	int | time.Month | time.Weekday
 }
@@ -420,14 +1892,60 @@ func next[T timeUnit, F bitfield](i, limit T, field F) (n T) {
 	return next
 }
 
-// String returns a string representation of the cron expression.
+// Location returns the time zone in which e is evaluated, or nil if e has no
+// "CRON_TZ=" or "TZ=" prefix, in which case e is evaluated in the location of
+// the time given to Next and Prev.
+func (e *Expr) Location() *time.Location {
+	return e.loc
+}
+
+// A RandomValue is a value picked at random for a "~" group when parsing an
+// expression. See WithRandom.
+type RandomValue struct {
+	Field Field
+	Group string // The group as found in the expression, e.g., "0~30".
+	Value int
+}
+
+// RandomValues returns the values picked at random when parsing e, in the
+// order in which they appear in the expression.
+func (e *Expr) RandomValues() []RandomValue {
+	return append([]RandomValue(nil), e.random...)
+}
+
+// String returns the text e was parsed from, or else its canonical form. See
+// Canonical.
 func (e *Expr) String() string {
+	if e.expr == "" {
+		return e.Canonical()
+	}
 	return e.expr
 }
 
-// MarshalText implements the encoding.TextMarshaler interface.
+// MarshalText implements the encoding.TextMarshaler interface. UnmarshalText
+// parses the text without options, so MarshalText returns an error if that
+// would not reproduce e, e.g., if e was parsed with WithSeconds, in which case
+// "*/15 * * * * *" would read as a years field, or with WithDialect.
 func (e *Expr) MarshalText() ([]byte, error) {
-	return []byte(e.expr), nil
+	text := e.String()
+	if text == "" {
+		return []byte(text), nil
+	}
+	if u, err := Parse(text); err != nil || !u.equal(e) {
+		return nil, fmt.Errorf("cron: marshaling %q: text does not parse back without options", text)
+	}
+	return []byte(text), nil
+}
+
+// equal reports whether e and u activate at the same times.
+func (e *Expr) equal(u *Expr) bool {
+	sameLoc := e.loc == u.loc || e.loc != nil && u.loc != nil && e.loc.String() == u.loc.String()
+	return e.s == u.s && e.m == u.m && e.h == u.h &&
+		e.dom == u.dom && e.domL == u.domL && e.domW == u.domW && e.domLW == u.domLW &&
+		e.mon == u.mon && e.dow == u.dow && e.dowN == u.dowN && e.dowL == u.dowL &&
+		e.y == u.y && e.lastYear == u.lastYear &&
+		sameLoc && e.dst == u.dst && e.daysOr == u.daysOr && e.seconds == u.seconds &&
+		e.every == u.every && e.anchor.Equal(u.anchor)
 }
 
 // UnmarshalText implements the encoding.TextUnmarshaler interface.
@@ -1,6 +1,9 @@
 package cron_test
 
 import (
+	"errors"
+	"math/rand"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,20 +15,24 @@ import (
 
 var cronRe *regexp.Regexp
 
+// secondsRe matches a seconds field, which has the same syntax as a minutes
+// field.
+var secondsRe string
+
 func init() {
 	fieldRes := [5]string{
 		`(?:[06-9]|[1-5][0-9]?)`,      // Match minutes 0-59.
 		`(?:[03-9]|1[0-9]?|2[0-3]?)`,  // Match hours 0-23.
 		`(?:[4-9]|[12][0-9]?|3[01]?)`, // Match days of month 1-31.
 		`(?:[2-9]|1[0-2]?)`,           // Match months 1-12.
-		`[0-6]`,                       // Match weekdays 0-6.
+		`[0-7]`,                       // Match weekdays 0-7.
 	}
 	fieldStepRes := [5]string{
 		`(?:[7-9]|[1-5][0-9]?|60?)`,   // Match 1-60.
 		`(?:[3-9]|1[0-9]?|2[0-4]?)`,   // Match 1-24.
 		`(?:[4-9]|[12][0-9]?|3[01]?)`, // Match 1-31.
 		`(?:[2-9]|1[0-2]?)`,           // Match 1-12.
-		`[1-7]`,                       // Match 1-7.
+		`[1-8]`,                       // Match 1-8.
 	}
 
 	matchZeroPadded := func(s string) string {
@@ -37,23 +44,40 @@ func init() {
 	}
 
 	// Match aliases
-	fieldRes[3] = `(?:` + fieldRes[3] + `|jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)`
-	fieldRes[4] = `(?:` + fieldRes[4] + `|sun|mon|tue|wed|thu|fri|sat)`
+	fieldRes[3] = `(?:` + fieldRes[3] + `|jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:tember)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)`
+	fieldRes[4] = `(?:` + fieldRes[4] + `|sun(?:day)?|mon(?:day)?|tue(?:sday)?|wed(?:nesday)?|thu(?:rsday)?|fri(?:day)?|sat(?:urday)?)`
 
 	for i, fieldRe := range fieldRes {
 		rangeRe := `(?:` + fieldRe + `(?:-` + fieldRe + `)?)` // Match range.
 		stepRe := `(?:/` + fieldStepRes[i] + `)`              // Match range step.
 		re := `(?:` + rangeRe + `)`                           // Match number or range or asterisk.
-		re = `(?:\*|(?:` + re + stepRe + `?))`                // Match asterisk or repetition.
-		re = re + `(?:,` + re + `)*`                          // Match lists of the above.
+		re = `(?:(?:\*|` + re + `)` + stepRe + `?)`           // Match asterisk or range, with optional step.
+		if i == 2 {
+			// Match nearest weekday and last day of month.
+			re = `(?:` + re + `|` + fieldRe + `w|l(?:-` + matchZeroPadded(`[0-9]|[12][0-9]|30`) + `)?w?)`
+		}
+		if i == 4 {
+			// Match nth and last day of week of the month.
+			re = `(?:` + re + `|` + fieldRe + `(?:#` + matchZeroPadded(`[1-5]`) + `|l))`
+		}
+		re = re + `(?:,` + re + `)*` // Match lists of the above.
 		fieldRes[i] = `(?:` + re + `)`
 	}
 
+	secondsRe = fieldRes[0]
+
 	re := fieldRes[0]
 	for _, fieldRe := range fieldRes[1:] {
 		re += ` ` + fieldRe // Match fields separated by space.
 	}
 
+	// Match an optional years field, 1970-2199, with steps 1-230.
+	yearRe := matchZeroPadded(`(?:19[7-9][0-9]|2[01][0-9][0-9])`)
+	yearStepRe := matchZeroPadded(`(?:[1-9][0-9]?|1[0-9][0-9]|2[0-2][0-9]|230)`)
+	yearRe = `(?:(?:\*|` + yearRe + `(?:-` + yearRe + `)?)(?:/` + yearStepRe + `)?)`
+	yearRe = yearRe + `(?:,` + yearRe + `)*`
+	re += `(?: ` + yearRe + `)?`
+
 	re = `^(?:` + re + `)$` // Match whole content.
 
 	cronRe = regexp.MustCompile(re)
@@ -63,6 +87,8 @@ func FuzzCron(f *testing.F) {
 	seed := []string{
 		"* * * * *",
 		"0/2 * * * *",
+		"*/2 * * * *",
+		"*/7 */5 */3 */4 */2",
 		"1-59/2 * * * *",
 		"0/3 * * * *",
 		"0/30 * * * *",
@@ -111,6 +137,96 @@ func FuzzCron(f *testing.F) {
 		"0 0 1 1 fri",
 		"0 0 1 1 sat",
 		"0 0 1 XXX XXX",
+		"0 0 1 1 * 2012",
+		"0 3 * * sun 2011-2012",
+		"0 0 29 2 * 2012,2013",
+		"0 0 * * * */2",
+		"0 0 * * * 2010/3",
+		"0 0 * * * 1969",
+		"0 0 * * * 2200",
+		"@yearly",
+		"@annually",
+		"@monthly",
+		"@weekly",
+		"@daily",
+		"@midnight",
+		"@hourly",
+		"@HOURLY",
+		"@minutely",
+		"@daily ",
+		"@every 90m",
+		"@every 1h30m",
+		"@every 0s",
+		"@every -1m",
+		"@every",
+		"0 0 L * *",
+		"0 0 l-2 * *",
+		"0 0 L-0,L-30 * *",
+		"0 0 1,L-1,15 * *",
+		"0 0 L * mon",
+		"0 0 L-28 2 *",
+		"0 0 L-29 2 *",
+		"0 0 L-29 4 *",
+		"0 0 L-30 4 *",
+		"0 0 L-31 * *",
+		"0 0 L- * *",
+		"0 0 L1 * *",
+		"0 0 L/2 * *",
+		"0 0 15W * *",
+		"0 0 1w * *",
+		"0 0 31W * *",
+		"0 0 LW * *",
+		"0 0 L-3W * *",
+		"0 0 1W,15W,LW * mon",
+		"0 0 31W 2 *",
+		"0 0 30W 4 *",
+		"0 0 L-29W 2 *",
+		"0 0 W * *",
+		"0 0 1-5W * *",
+		"0 0 *W * *",
+		"0 0 WL * *",
+		"0 10 * * 2#3",
+		"0 10 * * tue#1,fri#5",
+		"0 10 * * 5L",
+		"0 10 * * SATL,1",
+		"0 10 13 * 5#2",
+		"0 10 * * 0#6",
+		"0 10 * * 7#1",
+		"0 10 * * #1",
+		"0 10 * * 1#",
+		"0 10 * * L",
+		"0 10 * * 1-2L",
+		"0 0 * * 7",
+		"0 0 * * 1-7",
+		"0 0 * * 5-7",
+		"0 0 * * 0-7/2",
+		"0 0 * * */8",
+		"0 0 * * 8",
+		"0 0 * JANUARY MONDAY",
+		"0 0 * february-june sunday-Saturday",
+		"0 0 * Sept *",
+		"0 0 * * tues",
+		"0 0 * * sundayL,7L",
+		"0 22-2 * * *",
+		"0 22-2/2 * * *",
+		"50-10/3 * * * *",
+		"0 0 28-3 * *",
+		"0 0 30-1/2 * *",
+		"0 0 1 nov-feb *",
+		"0 0 * * fri-mon",
+		"0 0 * * sat-sun",
+		"0 0 * * 6-0",
+		"0 0 * * 7-1",
+		"0 0 * * 7-0",
+		"0 0 * * 5-1/2",
+		"0 0 1 * 1#2",
+		"0 0 L * 1#1",
+		"59 1,15 7 7 1#5",
+		"0 0 1W * sun",
+		"0 0 LW * 0,6",
+		"0 0 15W * 6",
+		"0 0 LW * 0",
+		"0 0 * * * 2199-1970/100",
 	}
 	for _, expr := range seed {
 		f.Add(expr)
@@ -120,6 +236,7 @@ func FuzzCron(f *testing.F) {
 	oneYearAfter := start.AddDate(1, 0, 0)
 
 	f.Fuzz(func(t *testing.T, cronExpr string) {
+		var perr *cron.ParseError
 		cron, err := cron.Parse(cronExpr)
 		tcron, ok := parseRefCron(cronExpr)
 		if err != nil && ok {
@@ -127,26 +244,93 @@ func FuzzCron(f *testing.F) {
 		} else if err == nil && !ok {
 			t.Fatalf("expected Parse not to accept %q", cronExpr)
 		}
+		if errors.As(err, &perr) && (perr.Offset < 0 || perr.Len < 0 || perr.Offset+perr.Len > len(cronExpr)) {
+			t.Fatalf("token of error out of %q: offset %d, length %d", cronExpr, perr.Offset, perr.Len)
+		}
 		if err != nil || tcron.isZero() {
 			return
 		}
+		compareRefCron(t, cron, tcron, start, oneYearAfter)
+	})
+}
 
-		from := start
-		for next := cron.Next(from); next.Before(oneYearAfter); next = cron.Next(from) {
-			if got, want := next, tcron.next(from); !got.Equal(want) {
-				t.Errorf("wrong next\ngot:  %v\nwant: %v", got, want)
+func TestSeconds(t *testing.T) {
+	tests := []string{
+		"* * * * * *",
+		"*/15 * * * * *",
+		"0 * * * * *",
+		"30 * * * * *",
+		"5-10,50 */7 * * * *",
+		"0/20 0 12 * * *",
+		"59 59 23 31 12 *",
+		"0 0 0 1 1 mon",
+	}
+	start := time.Date(2011, 12, 31, 12, 0, 0, 0, time.UTC)
+	oneDayAfter := start.AddDate(0, 0, 1)
+	for _, expr := range tests {
+		expr := expr
+		t.Run(expr, func(t *testing.T) {
+			cron, err := cron.Parse(expr, cron.WithSeconds())
+			if err != nil {
+				t.Fatal(err)
 			}
-			from = next
+			tcron, ok := parseRefCronSeconds(expr)
+			if !ok {
+				t.Fatalf("reference parser rejected %q", expr)
+			}
+			compareRefCron(t, cron, tcron, start, oneDayAfter)
+		})
+	}
+}
+
+func TestSecondsRequired(t *testing.T) {
+	if _, err := cron.Parse("* * * * *", cron.WithSeconds()); err == nil {
+		t.Error("expected Parse to reject a five-field expression")
+	}
+}
+
+func TestPrevPrecision(t *testing.T) {
+	from := time.Date(2022, 1, 1, 10, 0, 30, 0, time.UTC)
+	tests := []struct {
+		expr cron.Expr
+		want time.Time
+	}{
+		{cron.MustParse("* * * * *"), time.Date(2022, 1, 1, 9, 59, 0, 0, time.UTC)},
+		{cron.MustParse("0 * * * * *", cron.WithSeconds()), time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := tt.expr.Prev(from); !got.Equal(tt.want) {
+			t.Errorf("%v: wrong prev\ngot:  %v\nwant: %v", tt.expr.String(), got, tt.want)
 		}
+	}
+}
 
-		from = oneYearAfter
-		for prev := cron.Prev(from); prev.After(start); prev = cron.Prev(from) {
-			if got, want := prev, tcron.prev(from); !got.Equal(want) {
-				t.Errorf("wrong prev\ngot:  %v\nwant: %v", got, want)
-			}
-			from = prev
+// compareRefCron checks that cron and tcron agree on every activation
+// between start and end.
+func compareRefCron(t *testing.T, cron cron.Expr, tcron refCron, start, end time.Time) {
+	t.Helper()
+
+	for from := start; from.Before(end); {
+		next := cron.Next(from)
+		if got, want := next, tcron.next(from); !got.Equal(want) {
+			t.Fatalf("wrong next after %v\ngot:  %v\nwant: %v", from, got, want)
 		}
-	})
+		if next.IsZero() {
+			break
+		}
+		from = next
+	}
+
+	for from := end; from.After(start); {
+		prev := cron.Prev(from)
+		if got, want := prev, tcron.prev(from); !got.Equal(want) {
+			t.Fatalf("wrong prev before %v\ngot:  %v\nwant: %v", from, got, want)
+		}
+		if prev.IsZero() {
+			break
+		}
+		from = prev
+	}
 }
 
 func TestParseInvalidDom(t *testing.T) {
@@ -165,6 +349,50 @@ func TestParseInvalidDom(t *testing.T) {
 	}, {
 		expr: "* * 30,31 4,6,9,11 *",
 		fail: false,
+	}, {
+		expr: "* * L-29 2 *",
+		fail: true,
+	}, {
+		expr: "* * L-28 2 *",
+		fail: false,
+	}, {
+		expr: "* * 31,L-30 4 *",
+		fail: true,
+	}, {
+		// The second Monday is never the 1st.
+		expr: "0 0 1 * 1#2",
+		fail: true,
+	}, {
+		expr: "0 0 L * 1#1",
+		fail: true,
+	}, {
+		expr: "59 1,15 7 7 1#5",
+		fail: true,
+	}, {
+		expr: "0 0 8-14 * 1#2",
+		fail: false,
+	}, {
+		expr: "0 0 L * 5L",
+		fail: false,
+	}, {
+		expr: "0 0 29 2 1#5",
+		fail: false,
+	}, {
+		// The nearest weekday is never a weekend day.
+		expr: "0 0 1W * sun",
+		fail: true,
+	}, {
+		expr: "0 0 LW * 0,6",
+		fail: true,
+	}, {
+		expr: "0 0 15W * 6",
+		fail: true,
+	}, {
+		expr: "0 0 LW * 0",
+		fail: true,
+	}, {
+		expr: "0 0 1W * mon",
+		fail: false,
 	}}
 	for _, tt := range tests {
 		tt := tt
@@ -179,6 +407,979 @@ func TestParseInvalidDom(t *testing.T) {
 	}
 }
 
+func TestMarshalText(t *testing.T) {
+	tests := []struct {
+		expr string
+		opts []cron.Option
+		fail bool
+	}{
+		{"*/15 * * * *", nil, false},
+		{"0 0 * * * 2030", nil, false},
+		{"TZ=Europe/Berlin 0 9 * * mon-fri", nil, false},
+		{"@every 90m", nil, false},
+		{"0 0 1,15 * mon", nil, false},
+		{"*/15 * * * * *", []cron.Option{cron.WithSeconds()}, true},
+		{"0 0 1,15 * mon", []cron.Option{cron.WithDaysOr()}, true},
+		{"0 0 * * *", []cron.Option{cron.WithDST(cron.ShiftGap)}, true},
+		{"@every 90m", []cron.Option{cron.WithAnchor(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))}, true},
+		{"0 0 0 ? * MON", []cron.Option{cron.WithDialect(cron.DialectQuartz)}, true},
+		{"cron(0 10 * * ? *)", []cron.Option{cron.WithDialect(cron.DialectEventBridge)}, true},
+		{"Mon *-*-* 09:00", []cron.Option{cron.WithDialect(cron.DialectSystemd)}, true},
+		{"H * * * *", []cron.Option{cron.WithHash("job")}, true},
+	}
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		expr := cron.MustParse(tt.expr, tt.opts...)
+		text, err := expr.MarshalText()
+		if tt.fail {
+			if err == nil {
+				t.Errorf("expected MarshalText to reject %q, got %q", tt.expr, text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		var u cron.Expr
+		if err := u.UnmarshalText(text); err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		got, want := from, from
+		for i := 0; i < 10; i++ {
+			if got, want = u.Next(got), expr.Next(want); !got.Equal(want) {
+				t.Errorf("%q: wrong next after round trip\ngot:  %v\nwant: %v", tt.expr, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestMacroString(t *testing.T) {
+	for _, expr := range []string{"@daily", "@Weekly", "@midnight"} {
+		e := cron.MustParse(expr)
+		if got := e.String(); got != expr {
+			t.Errorf("wrong string\ngot:  %q\nwant: %q", got, expr)
+		}
+		var u cron.Expr
+		if err := u.UnmarshalText([]byte(expr)); err != nil {
+			t.Errorf("UnmarshalText(%q): %v", expr, err)
+		}
+	}
+
+	e := cron.MustParse("@hourly", cron.WithSeconds())
+	from := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	if got, want := e.Next(from), from.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("wrong next\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	anchor := time.Date(2022, 1, 1, 0, 0, 10, 0, time.UTC)
+	tests := []struct {
+		expr cron.Expr
+		from time.Time
+		next time.Time
+		prev time.Time
+	}{{
+		expr: cron.MustParse("@every 90m"),
+		from: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		next: time.Date(2022, 1, 1, 1, 30, 0, 0, time.UTC),
+		prev: time.Date(2021, 12, 31, 22, 30, 0, 0, time.UTC),
+	}, {
+		expr: cron.MustParse("@every 90m"),
+		from: time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC),
+		next: time.Date(2022, 1, 1, 1, 30, 0, 0, time.UTC),
+		prev: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		expr: cron.MustParse("@every 1h30m", cron.WithAnchor(anchor)),
+		from: anchor,
+		next: anchor.Add(90 * time.Minute),
+		prev: anchor.Add(-90 * time.Minute),
+	}, {
+		expr: cron.MustParse("@every 1h30m", cron.WithAnchor(anchor)),
+		from: anchor.Add(-time.Second),
+		next: anchor,
+		prev: anchor.Add(-90 * time.Minute),
+	}, {
+		expr: cron.MustParse("@every 7s", cron.WithAnchor(anchor)),
+		from: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC),
+		next: time.Date(1960, 1, 1, 0, 0, 2, 0, time.UTC),
+		prev: time.Date(1959, 12, 31, 23, 59, 55, 0, time.UTC),
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr.String(), func(t *testing.T) {
+			if got := tt.expr.Next(tt.from); !got.Equal(tt.next) {
+				t.Errorf("wrong next\ngot:  %v\nwant: %v", got, tt.next)
+			}
+			if got := tt.expr.Prev(tt.from); !got.Equal(tt.prev) {
+				t.Errorf("wrong prev\ngot:  %v\nwant: %v", got, tt.prev)
+			}
+		})
+	}
+}
+
+func TestLastDayOfMonth(t *testing.T) {
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"0 0 L * *", time.Date(2012, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2012, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 L * *", time.Date(2013, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2013, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{"0 0 L-2 * *", time.Date(2012, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2012, 2, 27, 0, 0, 0, 0, time.UTC)},
+		{"0 0 L-30 * *", time.Date(2012, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2012, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 L-30 * *", time.Date(2012, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2012, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			expr := cron.MustParse(tt.expr)
+			if got := expr.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("wrong next\ngot:  %v\nwant: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearestWeekday(t *testing.T) {
+	tests := []struct {
+		expr string
+		from time.Time
+		next time.Time
+		prev time.Time
+	}{{
+		// January 15th, 2022 is a Saturday.
+		expr: "0 0 15W * *",
+		from: time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC),
+		next: time.Date(2022, 1, 14, 0, 0, 0, 0, time.UTC),
+		prev: time.Date(2021, 12, 15, 0, 0, 0, 0, time.UTC),
+	}, {
+		// January 1st, 2022 is a Saturday.
+		expr: "0 0 1W * *",
+		from: time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC),
+		next: time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC),
+		prev: time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		// July 31st, 2022 is a Sunday.
+		expr: "0 0 LW * *",
+		from: time.Date(2022, 7, 20, 0, 0, 0, 0, time.UTC),
+		next: time.Date(2022, 7, 29, 0, 0, 0, 0, time.UTC),
+		prev: time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC),
+	}, {
+		// April has no 31st.
+		expr: "0 0 31W * *",
+		from: time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC),
+		next: time.Date(2022, 5, 31, 0, 0, 0, 0, time.UTC),
+		prev: time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC),
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			expr := cron.MustParse(tt.expr)
+			if got := expr.Next(tt.from); !got.Equal(tt.next) {
+				t.Errorf("wrong next\ngot:  %v\nwant: %v", got, tt.next)
+			}
+			if got := expr.Prev(tt.from); !got.Equal(tt.prev) {
+				t.Errorf("wrong prev\ngot:  %v\nwant: %v", got, tt.prev)
+			}
+		})
+	}
+}
+
+func TestNthDayOfWeek(t *testing.T) {
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		next time.Time
+		prev time.Time
+	}{
+		{"0 10 * * 2#3", time.Date(2022, 1, 18, 10, 0, 0, 0, time.UTC), time.Date(2021, 12, 21, 10, 0, 0, 0, time.UTC)},
+		{"0 10 * * 5L", time.Date(2022, 1, 28, 10, 0, 0, 0, time.UTC), time.Date(2021, 12, 31, 10, 0, 0, 0, time.UTC)},
+		{"0 10 * * mon#5", time.Date(2022, 1, 31, 10, 0, 0, 0, time.UTC), time.Date(2021, 11, 29, 10, 0, 0, 0, time.UTC)},
+		{"0 10 * 2 sun#5", time.Date(2032, 2, 29, 10, 0, 0, 0, time.UTC), time.Date(2004, 2, 29, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			expr := cron.MustParse(tt.expr)
+			if got := expr.Next(from); !got.Equal(tt.next) {
+				t.Errorf("wrong next\ngot:  %v\nwant: %v", got, tt.next)
+			}
+			if got := expr.Prev(from); !got.Equal(tt.prev) {
+				t.Errorf("wrong prev\ngot:  %v\nwant: %v", got, tt.prev)
+			}
+		})
+	}
+}
+
+func TestDaysOr(t *testing.T) {
+	tests := []struct {
+		expr   string
+		daysOr bool
+	}{
+		{"0 0 1,15 * mon", true},
+		{"0 0 L * 5L", true},
+		{"0 0 15W * 2#3", true},
+		{"0 0 * * mon", false},
+		{"0 0 */2 * mon", false},
+		{"0 0 1,15 * *", false},
+		{"0 0 1,15 * */2", false},
+		{"@weekly", false},
+	}
+	start := time.Date(2011, 12, 21, 0, 0, 0, 0, time.UTC)
+	oneYearAfter := start.AddDate(1, 0, 0)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			cron, err := cron.Parse(tt.expr, cron.WithDaysOr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			tcron, _ := parseRefCron(tt.expr)
+			tcron.daysOr = tt.daysOr
+			compareRefCron(t, cron, tcron, start, oneYearAfter)
+		})
+	}
+
+	// February 30th never happens, but Mondays in February do.
+	expr := cron.MustParse("0 0 30 2 mon", cron.WithDaysOr())
+	want := time.Date(2022, 2, 7, 0, 0, 0, 0, time.UTC)
+	if got := expr.Next(start.AddDate(10, 0, 0)); !got.Equal(want) {
+		t.Errorf("wrong next\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestWrapAround(t *testing.T) {
+	tests := []struct {
+		expr string
+		from time.Time
+		want []time.Time
+	}{{
+		expr: "0 22-2/2 * * *",
+		from: time.Date(2022, 1, 1, 21, 0, 0, 0, time.UTC),
+		want: []time.Time{
+			time.Date(2022, 1, 1, 22, 0, 0, 0, time.UTC),
+			time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2022, 1, 2, 2, 0, 0, 0, time.UTC),
+			time.Date(2022, 1, 2, 22, 0, 0, 0, time.UTC),
+		},
+	}, {
+		// January 1st, 2022 is a Saturday.
+		expr: "0 0 * * fri-mon",
+		from: time.Date(2021, 12, 30, 0, 0, 0, 0, time.UTC),
+		want: []time.Time{
+			time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC),
+			time.Date(2022, 1, 7, 0, 0, 0, 0, time.UTC),
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			expr := cron.MustParse(tt.expr)
+			from := tt.from
+			for _, want := range tt.want {
+				if got := expr.Next(from); !got.Equal(want) {
+					t.Fatalf("wrong next\ngot:  %v\nwant: %v", got, want)
+				}
+				from = want
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	firstRuns := func(expr cron.Expr) (runs [4]time.Time) {
+		t := from
+		for i := range runs {
+			t = expr.Next(t)
+			runs[i] = t
+		}
+		return runs
+	}
+
+	if _, err := cron.Parse("H * * * *"); err == nil {
+		t.Error("expected Parse to reject H without a hash key")
+	}
+
+	// The same key always yields the same expression.
+	a := cron.MustParse("H H * * *", cron.WithHash("backup"))
+	b := cron.MustParse("H H * * *", cron.WithHash("backup"))
+	if firstRuns(a) != firstRuns(b) {
+		t.Error("expected the same key to yield the same activations")
+	}
+
+	// Different keys spread activations.
+	minutes := make(map[int]bool)
+	for i := 0; i < 20; i++ {
+		expr := cron.MustParse("H * * * *", cron.WithHash("job"+strconv.Itoa(i)))
+		minutes[expr.Next(from).Minute()] = true
+	}
+	if len(minutes) < 10 {
+		t.Errorf("expected different keys to spread activations, got minutes %v", minutes)
+	}
+
+	tests := []struct {
+		expr  string
+		check func(runs [4]time.Time) bool
+	}{{
+		expr: "H(0-29) * * * *",
+		check: func(runs [4]time.Time) bool {
+			return runs[0].Minute() <= 29 && runs[1].Sub(runs[0]) == time.Hour
+		},
+	}, {
+		expr: "H/15 * * * *",
+		check: func(runs [4]time.Time) bool {
+			return runs[1].Sub(runs[0]) == 15*time.Minute &&
+				runs[2].Sub(runs[1]) == 15*time.Minute &&
+				runs[3].Sub(runs[2]) == 15*time.Minute
+		},
+	}, {
+		expr: "0 H(9-17)/2 * * *",
+		check: func(runs [4]time.Time) bool {
+			return runs[0].Hour() >= 9 && runs[0].Hour() <= 10 &&
+				runs[1].Sub(runs[0]) == 2*time.Hour
+		},
+	}, {
+		expr: "0 0 H * H(mon-fri)",
+		check: func(runs [4]time.Time) bool {
+			wd := runs[0].Weekday()
+			return runs[0].Day() <= 28 && wd >= time.Monday && wd <= time.Friday
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				expr := cron.MustParse(tt.expr, cron.WithHash("job"+strconv.Itoa(i)))
+				if runs := firstRuns(expr); !tt.check(runs) {
+					t.Fatalf("unexpected activations for key %q: %v", "job"+strconv.Itoa(i), runs)
+				}
+			}
+		})
+	}
+
+	for _, expr := range []string{"H(30-10) * * * *", "H(0-29 * * * *", "H0-29) * * * *", "H() * * * *", "H/ * * * *", "H/61 * * * *"} {
+		if _, err := cron.Parse(expr, cron.WithHash("job")); err == nil {
+			t.Errorf("expected Parse to reject %q", expr)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	if _, err := cron.Parse("0~30 * * * *"); err == nil {
+		t.Error("expected Parse to reject ~ without a random source")
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		expr, err := cron.Parse("0~30 ~ * * mon~", cron.WithRandom(r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := expr.RandomValues()
+		if len(values) != 3 {
+			t.Fatalf("wrong number of random values\ngot:  %v\nwant: 3", len(values))
+		}
+		want := []struct {
+			field    cron.Field
+			group    string
+			min, max int
+		}{
+			{cron.FieldMinutes, "0~30", 0, 30},
+			{cron.FieldHours, "~", 0, 23},
+			{cron.FieldDaysOfWeek, "mon~", 1, 7},
+		}
+		for i, v := range values {
+			if v.Field != want[i].field || v.Group != want[i].group || v.Value < want[i].min || v.Value > want[i].max {
+				t.Errorf("unexpected random value %+v", v)
+			}
+		}
+
+		next := expr.Next(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+		if next.Minute() != values[0].Value || next.Hour() != values[1].Value {
+			t.Errorf("activation %v doesn't match random values %+v", next, values)
+		}
+
+		again := cron.MustParse("0~30 ~ * * mon~", cron.WithRandom(rand.New(rand.NewSource(seed))))
+		if got := again.RandomValues(); !reflect.DeepEqual(got, values) {
+			t.Errorf("expected the same seed to yield the same values\ngot:  %v\nwant: %v", got, values)
+		}
+	}
+
+	for _, expr := range []string{"30~0 * * * *", "0~60 * * * *", "0~30/5 * * * *", "~~ * * * *"} {
+		if _, err := cron.Parse(expr, cron.WithRandom(nil)); err == nil {
+			t.Errorf("expected Parse to reject %q", expr)
+		}
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		expr string
+		from time.Time
+		next time.Time
+		prev time.Time
+	}{{
+		expr: "CRON_TZ=Europe/Berlin 0 9 * * *",
+		from: time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+		next: time.Date(2022, 1, 2, 9, 0, 0, 0, berlin),
+		prev: time.Date(2022, 1, 1, 9, 0, 0, 0, berlin),
+	}, {
+		expr: "CRON_TZ=Europe/Berlin 0 9 * * *",
+		from: time.Date(2022, 1, 1, 7, 0, 0, 0, newYork),
+		next: time.Date(2022, 1, 2, 9, 0, 0, 0, berlin),
+		prev: time.Date(2022, 1, 1, 9, 0, 0, 0, berlin),
+	}, {
+		expr: "TZ=America/New_York @daily",
+		from: time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC),
+		next: time.Date(2022, 7, 2, 0, 0, 0, 0, newYork),
+		prev: time.Date(2022, 7, 1, 0, 0, 0, 0, newYork),
+	}, {
+		expr: "TZ=UTC 30 * * * * *",
+		from: time.Date(2022, 1, 1, 0, 0, 0, 0, berlin),
+		next: time.Date(2021, 12, 31, 23, 30, 0, 0, time.UTC),
+		prev: time.Date(2021, 12, 31, 22, 30, 0, 0, time.UTC),
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			expr := cron.MustParse(tt.expr)
+			if got := expr.Next(tt.from); !got.Equal(tt.next) || got.Location() != expr.Location() {
+				t.Errorf("wrong next\ngot:  %v\nwant: %v", got, tt.next)
+			}
+			if got := expr.Prev(tt.from); !got.Equal(tt.prev) || got.Location() != expr.Location() {
+				t.Errorf("wrong prev\ngot:  %v\nwant: %v", got, tt.prev)
+			}
+
+			text, err := expr.MarshalText()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(text) != tt.expr {
+				t.Errorf("wrong text\ngot:  %q\nwant: %q", text, tt.expr)
+			}
+			var u cron.Expr
+			if err := u.UnmarshalText(text); err != nil {
+				t.Fatal(err)
+			}
+			if got := u.Next(tt.from); !got.Equal(tt.next) {
+				t.Errorf("wrong next after round trip\ngot:  %v\nwant: %v", got, tt.next)
+			}
+		})
+	}
+
+	for _, expr := range []string{"CRON_TZ=Nowhere/Special 0 9 * * *", "CRON_TZ= 0 9 * * *", "TZ=UTC"} {
+		if _, err := cron.Parse(expr); err == nil {
+			t.Errorf("expected Parse to reject %q", expr)
+		}
+	}
+}
+
+func TestDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skip(err)
+	}
+	utc := func(y int, mon time.Month, d, h, m int) time.Time {
+		return time.Date(y, mon, d, h, m, 0, 0, time.UTC)
+	}
+
+	// In New York, clocks moved from 02:00 to 03:00 on 2022-03-13, and from
+	// 02:00 back to 01:00 on 2022-11-06. In Sao Paulo, they moved from 00:00
+	// to 01:00 on 2018-11-04, and from 00:00 back to 23:00 on 2019-02-16.
+	tests := []struct {
+		expr   string
+		loc    *time.Location
+		policy cron.DSTPolicy
+		from   time.Time
+		next   time.Time
+		prev   time.Time
+	}{{
+		expr:   "30 2 * * *",
+		loc:    newYork,
+		policy: cron.SkipGap,
+		from:   utc(2022, 3, 13, 12, 0),
+		next:   utc(2022, 3, 14, 6, 30), // 02:30 EDT
+		prev:   utc(2022, 3, 12, 7, 30), // 02:30 EST
+	}, {
+		expr:   "30 2 * * *",
+		loc:    newYork,
+		policy: cron.ShiftGap,
+		from:   utc(2022, 3, 13, 12, 0),
+		next:   utc(2022, 3, 14, 6, 30), // 02:30 EDT
+		prev:   utc(2022, 3, 13, 7, 30), // 03:30 EDT
+	}, {
+		expr:   "30 2 * * *",
+		loc:    newYork,
+		policy: cron.ShiftGap,
+		from:   utc(2022, 3, 13, 7, 15), // 03:15 EDT
+		next:   utc(2022, 3, 13, 7, 30), // 03:30 EDT
+		prev:   utc(2022, 3, 12, 7, 30), // 02:30 EST
+	}, {
+		expr:   "*/20 * * * *",
+		loc:    newYork,
+		policy: cron.ShiftGap,
+		from:   utc(2022, 3, 13, 6, 50), // 01:50 EST
+		next:   utc(2022, 3, 13, 7, 0),  // 03:00 EDT
+		prev:   utc(2022, 3, 13, 6, 40), // 01:40 EST
+	}, {
+		expr:   "30 1 * * *",
+		loc:    newYork,
+		policy: cron.OnceInOverlap,
+		from:   utc(2022, 11, 6, 5, 45), // 01:45 EDT
+		next:   utc(2022, 11, 7, 6, 30), // 01:30 EST
+		prev:   utc(2022, 11, 6, 5, 30), // 01:30 EDT
+	}, {
+		expr:   "30 1 * * *",
+		loc:    newYork,
+		policy: cron.TwiceInOverlap,
+		from:   utc(2022, 11, 6, 5, 45), // 01:45 EDT
+		next:   utc(2022, 11, 6, 6, 30), // 01:30 EST
+		prev:   utc(2022, 11, 6, 5, 30), // 01:30 EDT
+	}, {
+		expr:   "30 1 * * *",
+		loc:    newYork,
+		policy: cron.OnceInOverlap,
+		from:   utc(2022, 11, 6, 6, 45), // 01:45 EST
+		next:   utc(2022, 11, 7, 6, 30), // 01:30 EST
+		prev:   utc(2022, 11, 6, 5, 30), // 01:30 EDT
+	}, {
+		expr:   "30 1 * * *",
+		loc:    newYork,
+		policy: cron.TwiceInOverlap,
+		from:   utc(2022, 11, 6, 6, 15), // 01:15 EST
+		next:   utc(2022, 11, 6, 6, 30), // 01:30 EST
+		prev:   utc(2022, 11, 6, 5, 30), // 01:30 EDT
+	}, {
+		expr:   "*/30 * * * *",
+		loc:    newYork,
+		policy: cron.OnceInOverlap,
+		from:   utc(2022, 11, 6, 5, 45), // 01:45 EDT
+		next:   utc(2022, 11, 6, 7, 0),  // 02:00 EST
+		prev:   utc(2022, 11, 6, 5, 30), // 01:30 EDT
+	}, {
+		expr:   "*/30 * * * *",
+		loc:    newYork,
+		policy: cron.TwiceInOverlap,
+		from:   utc(2022, 11, 6, 5, 45), // 01:45 EDT
+		next:   utc(2022, 11, 6, 6, 0),  // 01:00 EST
+		prev:   utc(2022, 11, 6, 5, 30), // 01:30 EDT
+	}, {
+		expr:   "@daily",
+		loc:    saoPaulo,
+		policy: cron.SkipGap,
+		from:   utc(2018, 11, 3, 12, 0),
+		next:   utc(2018, 11, 5, 2, 0), // 00:00 -02
+		prev:   utc(2018, 11, 3, 3, 0), // 00:00 -03
+	}, {
+		expr:   "@daily",
+		loc:    saoPaulo,
+		policy: cron.ShiftGap,
+		from:   utc(2018, 11, 3, 12, 0),
+		next:   utc(2018, 11, 4, 3, 0), // 01:00 -02
+		prev:   utc(2018, 11, 3, 3, 0), // 00:00 -03
+	}, {
+		expr:   "30 23 * * *",
+		loc:    saoPaulo,
+		policy: cron.OnceInOverlap,
+		from:   utc(2019, 2, 17, 1, 45), // 23:45 -02
+		next:   utc(2019, 2, 18, 2, 30), // 23:30 -03
+		prev:   utc(2019, 2, 17, 1, 30), // 23:30 -02
+	}, {
+		expr:   "30 23 * * *",
+		loc:    saoPaulo,
+		policy: cron.TwiceInOverlap,
+		from:   utc(2019, 2, 17, 1, 45), // 23:45 -02
+		next:   utc(2019, 2, 17, 2, 30), // 23:30 -03
+		prev:   utc(2019, 2, 17, 1, 30), // 23:30 -02
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.loc.String()+" "+tt.expr, func(t *testing.T) {
+			expr := cron.MustParse(tt.expr, cron.WithDST(tt.policy))
+			from := tt.from.In(tt.loc)
+			if got := expr.Next(from); !got.Equal(tt.next) {
+				t.Errorf("wrong next\ngot:  %v\nwant: %v", got, tt.next.In(tt.loc))
+			}
+			if got := expr.Prev(from); !got.Equal(tt.prev) {
+				t.Errorf("wrong prev\ngot:  %v\nwant: %v", got, tt.prev.In(tt.loc))
+			}
+		})
+	}
+}
+
+// TestDSTReference compares Next and Prev around transitions to activation
+// times found by checking every minute.
+func TestDSTReference(t *testing.T) {
+	zones := []struct {
+		name string
+		at   time.Time
+	}{
+		{"America/New_York", time.Date(2022, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"America/New_York", time.Date(2022, 11, 6, 0, 0, 0, 0, time.UTC)},
+		{"America/Sao_Paulo", time.Date(2018, 11, 4, 0, 0, 0, 0, time.UTC)},
+		{"America/Sao_Paulo", time.Date(2019, 2, 17, 0, 0, 0, 0, time.UTC)},
+		{"Australia/Lord_Howe", time.Date(2022, 4, 3, 0, 0, 0, 0, time.UTC)},
+		{"Australia/Lord_Howe", time.Date(2022, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{"Europe/Berlin", time.Date(2022, 3, 27, 0, 0, 0, 0, time.UTC)},
+		{"Europe/Berlin", time.Date(2022, 10, 30, 0, 0, 0, 0, time.UTC)},
+	}
+	exprs := []string{
+		"* * * * *",
+		"*/20 * * * *",
+		"15,45 1-3 * * *",
+		"30 2 * * *",
+		"0 0 * * *",
+		"10 23 * * *",
+		"0 */2 * * *",
+	}
+	policies := []cron.DSTPolicy{
+		cron.SkipGap | cron.OnceInOverlap,
+		cron.SkipGap | cron.TwiceInOverlap,
+		cron.ShiftGap | cron.OnceInOverlap,
+		cron.ShiftGap | cron.TwiceInOverlap,
+	}
+
+	wall := func(t time.Time) time.Time {
+		y, mon, d := t.Date()
+		h, m, s := t.Clock()
+		return time.Date(y, mon, d, h, m, s, 0, time.UTC)
+	}
+
+	for _, zone := range zones {
+		loc, err := time.LoadLocation(zone.name)
+		if err != nil {
+			t.Skip(err)
+		}
+		start, end := zone.at.Add(-48*time.Hour), zone.at.Add(48*time.Hour)
+		for _, s := range exprs {
+			utcExpr := cron.MustParse(s)
+			matches := func(w time.Time) bool {
+				return utcExpr.Next(w.Add(-time.Minute)).Equal(w)
+			}
+			for _, policy := range policies {
+				var acts []time.Time
+				for i := start; i.Before(end); i = i.Add(time.Minute) {
+					w := wall(i.In(loc))
+					first := true
+					for _, d := range []time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour} {
+						if wall(i.Add(-d).In(loc)).Equal(w) {
+							first = false
+						}
+					}
+					if matches(w) && (first || policy&cron.TwiceInOverlap != 0) {
+						acts = append(acts, i)
+					}
+					if policy&cron.ShiftGap != 0 && !matches(w) {
+						// Check whether a wall clock time skipped right
+						// before i is shifted to i, using the UTC offset
+						// from before the gap.
+						_, before := i.Add(-3 * time.Hour).In(loc).Zone()
+						_, after := i.In(loc).Zone()
+						for d := time.Minute; before != after && d <= 2*time.Hour; d += time.Minute {
+							g := w.Add(-d)
+							skipped := !wall(time.Unix(g.Unix()-int64(before), 0).In(loc)).Equal(g) &&
+								!wall(time.Unix(g.Unix()-int64(after), 0).In(loc)).Equal(g)
+							if skipped && g.Add(-time.Duration(before)*time.Second).Equal(i) && matches(g) {
+								acts = append(acts, i)
+								break
+							}
+						}
+					}
+				}
+
+				expr := cron.MustParse(s, cron.WithDST(policy))
+				for k := 1; k < len(acts)-1; k++ {
+					for _, from := range []time.Time{acts[k-1], acts[k].Add(-30 * time.Second)} {
+						if got := expr.Next(from.In(loc)); !got.Equal(acts[k]) {
+							t.Fatalf("%s %q policy %d: wrong next after %v\ngot:  %v\nwant: %v", zone.name, s, policy, from.In(loc), got, acts[k].In(loc))
+						}
+					}
+					for _, from := range []time.Time{acts[k+1], acts[k].Add(time.Minute)} {
+						if got := expr.Prev(from.In(loc)); !got.Equal(acts[k]) {
+							t.Fatalf("%s %q policy %d: wrong prev before %v\ngot:  %v\nwant: %v", zone.name, s, policy, from.In(loc), got, acts[k].In(loc))
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestYears(t *testing.T) {
+	tests := []struct {
+		expr string
+		from time.Time
+		next time.Time
+		prev time.Time
+	}{{
+		expr: "0 3 * * sun 2027-2028",
+		from: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		next: time.Date(2027, 1, 3, 3, 0, 0, 0, time.UTC),
+		prev: time.Time{},
+	}, {
+		expr: "0 3 * * sun 2027-2028",
+		from: time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC),
+		next: time.Time{},
+		prev: time.Date(2028, 12, 31, 3, 0, 0, 0, time.UTC),
+	}, {
+		expr: "0 0 29 2 * 2027",
+		from: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		next: time.Time{},
+		prev: time.Time{},
+	}, {
+		expr: "0 0 1 1 * *",
+		from: time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC),
+		next: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+		prev: time.Time{},
+	}, {
+		expr: "0 0 1 1 * *",
+		from: time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC),
+		next: time.Time{},
+		prev: time.Date(2199, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			expr := cron.MustParse(tt.expr)
+			if got := expr.Next(tt.from); !got.Equal(tt.next) {
+				t.Errorf("wrong next\ngot:  %v\nwant: %v", got, tt.next)
+			}
+			if got := expr.Prev(tt.from); !got.Equal(tt.prev) {
+				t.Errorf("wrong prev\ngot:  %v\nwant: %v", got, tt.prev)
+			}
+		})
+	}
+}
+
+func TestDialects(t *testing.T) {
+	tests := []struct {
+		expr    string
+		dialect cron.Dialect
+		ok      bool
+	}{
+		{"0 0 1 * 1", cron.DialectPOSIX, true},
+		{"0 0 1 * 7", cron.DialectPOSIX, false},
+		{"0 0 L * *", cron.DialectPOSIX, false},
+		{"0 0 15W * *", cron.DialectPOSIX, false},
+		{"0 0 * * 5L", cron.DialectPOSIX, false},
+		{"0 0 * * 1#2", cron.DialectPOSIX, false},
+		{"0 0 * * * 2022", cron.DialectPOSIX, false},
+		{"@daily", cron.DialectPOSIX, false},
+		{"CRON_TZ=UTC 0 0 * * *", cron.DialectPOSIX, false},
+		{"0 0 1 * 7", cron.DialectVixie, true},
+		{"@weekly", cron.DialectVixie, true},
+		{"CRON_TZ=UTC 0 0 * * *", cron.DialectVixie, true},
+		{"0 0 L * *", cron.DialectVixie, false},
+		{"@every 1h", cron.DialectKubernetes, true},
+		{"0 0 * * 7", cron.DialectKubernetes, false},
+		{"TZ=UTC 0 0 * * *", cron.DialectKubernetes, false},
+		{"0 0 L * *", cron.DialectDefault, true},
+		{"0 0 * * * 2022", cron.DialectDefault, true},
+	}
+	for _, tt := range tests {
+		_, err := cron.NewParser(cron.WithDialect(tt.dialect)).Parse(tt.expr)
+		if (err == nil) != tt.ok {
+			t.Errorf("unexpected result parsing %q with dialect %d: %v", tt.expr, tt.dialect, err)
+		}
+	}
+
+	// Dialects other than the default OR the days fields.
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	posix := cron.NewParser(cron.WithDialect(cron.DialectPOSIX)).MustParse("0 0 13 * 5")
+	if got, want := posix.Next(from), time.Date(2022, 1, 7, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("wrong next\ngot:  %v\nwant: %v", got, want)
+	}
+
+	// Kubernetes follows robfig/cron, where "*/2" restricts the days of week,
+	// so that it ORs them with the days of month, unlike POSIX.
+	for _, tt := range []struct {
+		dialect cron.Dialect
+		want    time.Time
+	}{
+		{cron.DialectKubernetes, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{cron.DialectPOSIX, time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		expr := cron.NewParser(cron.WithDialect(tt.dialect)).MustParse("0 0 1 * */2")
+		if got := expr.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%v: wrong next\ngot:  %v\nwant: %v", tt.dialect, got, tt.want)
+		}
+	}
+	k8s := cron.NewParser(cron.WithDialect(cron.DialectKubernetes)).MustParse("0 0 1 * *,*/2")
+	if got, want := k8s.Next(from), time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("wrong next\ngot:  %v\nwant: %v", got, want)
+	}
+
+	// Options given after a dialect override it.
+	p := cron.NewParser(cron.WithDialect(cron.DialectPOSIX), cron.WithSeconds(), cron.WithFeatures(cron.FeatureYears))
+	if _, err := p.Parse("0 0 0 * * * 2022"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParser(t *testing.T) {
+	p := cron.NewParser()
+	for _, s := range []string{"*/5 * * * *", "0 0 L * *", "@hourly", "0 0 * * 7"} {
+		want := cron.MustParse(s)
+		got := p.MustParse(s)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Parser and Parse disagree on %q", s)
+		}
+	}
+
+	// Values picked at random by one call don't leak into the next.
+	p = cron.NewParser(cron.WithRandom(rand.New(rand.NewSource(1))))
+	for i := 0; i < 3; i++ {
+		expr := p.MustParse("~ * * * *")
+		if got := len(expr.RandomValues()); got != 1 {
+			t.Fatalf("wrong number of random values\ngot:  %v\nwant: 1", got)
+		}
+	}
+
+	features := cron.AllFeatures &^ cron.FeatureYears
+	if _, err := cron.Parse("0 0 * * * 2022", cron.WithFeatures(features)); err == nil {
+		t.Error("expected Parse to reject a years field")
+	}
+	if _, err := cron.Parse("0 0 * * 0-6", cron.WithFeatures(features)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQuartz(t *testing.T) {
+	quartz := cron.NewParser(cron.WithDialect(cron.DialectQuartz))
+	utc := func(y int, mon time.Month, d, h, m, s int) time.Time {
+		return time.Date(y, mon, d, h, m, s, 0, time.UTC)
+	}
+
+	// Examples from the Quartz documentation, starting on Saturday 2022-01-01.
+	from := utc(2022, 1, 1, 0, 0, 0)
+	tests := []struct {
+		expr string
+		next []time.Time
+	}{{
+		expr: "0 0 12 * * ?",
+		next: []time.Time{utc(2022, 1, 1, 12, 0, 0), utc(2022, 1, 2, 12, 0, 0)},
+	}, {
+		expr: "0 15 10 * * ? 2023",
+		next: []time.Time{utc(2023, 1, 1, 10, 15, 0), utc(2023, 1, 2, 10, 15, 0)},
+	}, {
+		expr: "0 0/5 14,18 * * ?",
+		next: []time.Time{utc(2022, 1, 1, 14, 0, 0), utc(2022, 1, 1, 14, 5, 0)},
+	}, {
+		expr: "0 10,44 14 ? 3 WED",
+		next: []time.Time{utc(2022, 3, 2, 14, 10, 0), utc(2022, 3, 2, 14, 44, 0)},
+	}, {
+		expr: "0 15 10 ? * MON-FRI",
+		next: []time.Time{utc(2022, 1, 3, 10, 15, 0), utc(2022, 1, 4, 10, 15, 0)},
+	}, {
+		expr: "0 15 10 ? * 2-6",
+		next: []time.Time{utc(2022, 1, 3, 10, 15, 0), utc(2022, 1, 4, 10, 15, 0)},
+	}, {
+		expr: "0 15 10 L-2 * ?",
+		next: []time.Time{utc(2022, 1, 29, 10, 15, 0), utc(2022, 2, 26, 10, 15, 0)},
+	}, {
+		expr: "0 15 10 ? * 6L",
+		next: []time.Time{utc(2022, 1, 28, 10, 15, 0), utc(2022, 2, 25, 10, 15, 0)},
+	}, {
+		expr: "0 15 10 ? * 6#3",
+		next: []time.Time{utc(2022, 1, 21, 10, 15, 0), utc(2022, 2, 18, 10, 15, 0)},
+	}, {
+		expr: "0 0 12 1/5 * ?",
+		next: []time.Time{utc(2022, 1, 1, 12, 0, 0), utc(2022, 1, 6, 12, 0, 0)},
+	}, {
+		expr: "0 0 0 LW * ?",
+		next: []time.Time{utc(2022, 1, 31, 0, 0, 0), utc(2022, 2, 28, 0, 0, 0)},
+	}, {
+		expr: "0 0 0 ? * L",
+		next: []time.Time{utc(2022, 1, 8, 0, 0, 0), utc(2022, 1, 15, 0, 0, 0)},
+	}, {
+		expr: "0 0 0 ? * 1",
+		next: []time.Time{utc(2022, 1, 2, 0, 0, 0), utc(2022, 1, 9, 0, 0, 0)},
+	}, {
+		expr: "0 0 0 ? * SAT-MON",
+		next: []time.Time{utc(2022, 1, 2, 0, 0, 0), utc(2022, 1, 3, 0, 0, 0), utc(2022, 1, 8, 0, 0, 0)},
+	}, {
+		expr: "0 0 0 ? * 7-2",
+		next: []time.Time{utc(2022, 1, 2, 0, 0, 0), utc(2022, 1, 3, 0, 0, 0), utc(2022, 1, 8, 0, 0, 0)},
+	}, {
+		expr: "0 0 0 1 1 ? 2099",
+		next: []time.Time{utc(2099, 1, 1, 0, 0, 0), {}},
+	}}
+	for _, tt := range tests {
+		expr, err := quartz.Parse(tt.expr)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		got := from
+		for _, want := range tt.next {
+			if got = expr.Next(got); !got.Equal(want) {
+				t.Errorf("%q: wrong next\ngot:  %v\nwant: %v", tt.expr, got, want)
+				break
+			}
+		}
+	}
+
+	// Years end at 2099 even if the years field is "*" or missing.
+	for _, s := range []string{"0 0 0 1 1 ? *", "0 0 0 1 1 ?"} {
+		expr, err := quartz.Parse(s)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if got := expr.Next(utc(2099, 1, 1, 0, 0, 0)); !got.IsZero() {
+			t.Errorf("%q: wrong next\ngot:  %v\nwant: %v", s, got, time.Time{})
+		}
+		want := utc(2099, 1, 1, 0, 0, 0)
+		if got := expr.Prev(utc(2150, 1, 1, 0, 0, 0)); !got.Equal(want) {
+			t.Errorf("%q: wrong prev\ngot:  %v\nwant: %v", s, got, want)
+		}
+	}
+
+	for _, expr := range []string{
+		"0 0 12 * *",          // No seconds field.
+		"0 0 12 * * MON",      // No "?".
+		"0 0 12 ? * ?",        // Two "?".
+		"0 0 12 * ? * *",      // "?" outside of the days fields.
+		"0 0 12 ?,1 * MON",    // "?" with other days.
+		"0 0 12 ? * 0",        // Sunday is 1.
+		"0 0 12 ? * 8",        // Saturday is 7.
+		"0 0 12 ? * MONDAY",   // Names have three letters.
+		"0 0 12 L,15 * ?",     // L with other days.
+		"0 0 12 1W,15 * ?",    // W with other days.
+		"0 0 12 ? * 6L,2",     // L with other days.
+		"0 0 12 ? * 6#3,6#1",  // # with other days.
+		"0 0 12 * * ? 2100",   // Years end at 2099.
+		"0 0 12 * * ? 2022 1", // Too many fields.
+		"@daily",
+		"CRON_TZ=UTC 0 0 12 * * ?",
+	} {
+		if _, err := quartz.Parse(expr); err == nil {
+			t.Errorf("expected Parse to reject %q", expr)
+		}
+	}
+}
+
 func BenchmarkParse(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		cron.Parse("1 2-3 4/5 6,jul SUN")
@@ -245,22 +1446,103 @@ func BenchmarkPrev(b *testing.B) {
 
 // refCron is a "gold standard" cron expression parser.
 type refCron struct {
-	expr   string
-	fields [5][64]bool
+	expr     string
+	fields   [5][64]bool
+	domL     [31]bool
+	domW     [32]bool
+	domLW    [31]bool
+	dowN     [7][6]bool
+	dowL     [7]bool
+	daysOr   bool
+	seconds  [64]bool
+	years    [2200]bool
+	hasYears bool
+	step     time.Duration
+}
+
+var refMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
 }
 
 func parseRefCron(expr string) (c refCron, ok bool) {
+	if len(expr) >= len("@every ") && strings.EqualFold(expr[:len("@every ")], "@every ") {
+		// Interval expressions are accepted, but not compared. Units of
+		// durations are case-sensitive.
+		d, err := time.ParseDuration(expr[len("@every "):])
+		return c, err == nil && d > 0
+	}
 	expr = strings.ToLower(expr)
+	c.expr = expr
+	if macro, ok := refMacros[expr]; ok {
+		expr = macro
+	}
 	if !validCron(expr) {
 		return c, false
 	}
-	c.expr = expr
 	fields := c.fields[:]
-	for i, f := range strings.SplitN(expr, " ", 5) {
+	for i, f := range strings.SplitN(expr, " ", 6) {
+		if i == 5 {
+			c.hasYears = true
+			if !parseCronField(c.years[:], f, i) {
+				return c, false
+			}
+			continue
+		}
+		if i == 2 {
+			if !parseRefDom(fields[i][:], c.domL[:], c.domW[:], c.domLW[:], f) {
+				return c, false
+			}
+			continue
+		}
+		if i == 4 {
+			if !c.parseDow(f) {
+				return c, false
+			}
+			continue
+		}
 		if !parseCronField(fields[i][:], f, i) {
 			return c, false
 		}
 	}
+	c.seconds[0] = true
+	c.step = time.Minute
+	return c, c.hasDays()
+}
+
+// hasDays reports whether c matches any day of its months between 2000 and
+// 2027, which have every combination of the length of a month and the day of
+// week it starts on.
+func (c *refCron) hasDays() bool {
+	for t := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC); t.Year() < 2028; t = t.AddDate(0, 0, 1) {
+		y, mon, dom := t.Date()
+		if c.fields[3][mon] && c.matchDay(y, mon, dom, t.Weekday()) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRefCronSeconds(expr string) (c refCron, ok bool) {
+	s, rest, _ := strings.Cut(expr, " ")
+	if c, ok = parseRefCron(rest); !ok {
+		return c, false
+	}
+	// Seconds share the syntax of minutes.
+	if !regexp.MustCompile(`^` + secondsRe + `$`).MatchString(s) {
+		return c, false
+	}
+	c.seconds = [64]bool{}
+	if !parseCronField(c.seconds[:], s, 0) {
+		return c, false
+	}
+	c.expr = expr
+	c.step = time.Second
 	return c, true
 }
 
@@ -269,27 +1551,32 @@ func (c *refCron) isZero() bool {
 }
 
 func (c *refCron) next(from time.Time) time.Time {
-	t := from.Add(time.Minute)
+	t := from.Add(c.step)
+	fs := c.seconds
 	fm := c.fields[0]
 	fh := c.fields[1]
-	fdom := c.fields[2]
 	fmon := c.fields[3]
-	fdow := c.fields[4]
 
 	var y int
 	var mon time.Month
 	var dom int
 	var dow time.Weekday
-	var h, m int
+	var h, m, s int
 day:
 	for {
 		y, mon, dom = t.Date()
 		dow = t.Weekday()
 		switch {
+		case c.hasYears && (y >= len(c.years) || !c.years[y]):
+			if y >= len(c.years)-1 {
+				return time.Time{}
+			}
+			y++
+			mon, dom = 1, 1
 		case !fmon[mon]:
 			mon++
 			dom = 1
-		case !fdom[dom] || !fdow[dow]:
+		case !c.matchDay(y, mon, dom, dow):
 			dom++
 		default:
 			break day
@@ -299,17 +1586,20 @@ day:
 	doy := t.YearDay()
 hour:
 	for {
-		h, m, _ = t.Clock()
+		h, m, s = t.Clock()
 		switch {
 		case !fh[h]:
 			h++
-			m = 0
+			m, s = 0, 0
 		case !fm[m]:
 			m++
+			s = 0
+		case !fs[s]:
+			s++
 		default:
 			break hour
 		}
-		t = time.Date(y, mon, dom, h, m, 0, 0, t.Location())
+		t = time.Date(y, mon, dom, h, m, s, 0, t.Location())
 		if t.YearDay() != doy {
 			goto day
 		}
@@ -318,45 +1608,52 @@ hour:
 }
 
 func (c *refCron) prev(from time.Time) time.Time {
-	t := from.Add(-time.Minute)
+	t := from.Add(-c.step)
+	fs := c.seconds
 	fm := c.fields[0]
 	fh := c.fields[1]
-	fdom := c.fields[2]
 	fmon := c.fields[3]
-	fdow := c.fields[4]
 
 	var y int
 	var mon time.Month
 	var dom int
 	var dow time.Weekday
-	var h, m int
+	var h, m, s int
 day:
 	for {
 		y, mon, dom = t.Date()
 		dow = t.Weekday()
 		switch {
+		case c.hasYears && (y >= len(c.years) || !c.years[y]):
+			if y <= 1970 {
+				return time.Time{}
+			}
+			mon, dom = 1, 0
 		case !fmon[mon]:
 			dom = 0
-		case !fdom[dom] || !fdow[dow]:
+		case !c.matchDay(y, mon, dom, dow):
 			dom--
 		default:
 			break day
 		}
-		t = time.Date(y, mon, dom, 23, 59, 0, 0, t.Location())
+		t = time.Date(y, mon, dom, 23, 59, 59, 0, t.Location())
 	}
 	doy := t.YearDay()
 hour:
 	for {
-		h, m, _ = t.Clock()
+		h, m, s = t.Clock()
 		switch {
 		case !fh[h]:
-			m = -1
+			m, s = -1, 59
 		case !fm[m]:
 			m--
+			s = 59
+		case !fs[s]:
+			s--
 		default:
 			break hour
 		}
-		t = time.Date(y, mon, dom, h, m, 0, 0, t.Location())
+		t = time.Date(y, mon, dom, h, m, s, 0, t.Location())
 		if t.YearDay() != doy {
 			// We hit a different day.
 			goto day
@@ -365,13 +1662,76 @@ hour:
 	return t
 }
 
+func (c *refCron) matchDay(y int, mon time.Month, dom int, dow time.Weekday) bool {
+	if c.daysOr {
+		return c.matchDom(y, mon, dom) || c.matchDow(y, mon, dom, dow)
+	}
+	return c.matchDom(y, mon, dom) && c.matchDow(y, mon, dom, dow)
+}
+
+func (c *refCron) matchDom(y int, mon time.Month, dom int) bool {
+	last := time.Date(y, mon+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if c.fields[2][dom] || c.domL[last-dom] {
+		return true
+	}
+
+	// Look for the day whose nearest weekday is dom.
+	isWeekday := func(d int) bool {
+		wd := time.Date(y, mon, d, 0, 0, 0, 0, time.UTC).Weekday()
+		return d >= 1 && d <= last && wd != time.Saturday && wd != time.Sunday
+	}
+	nearest := func(d int) int {
+		for dist := 0; dist <= 3; dist++ {
+			if isWeekday(d - dist) {
+				return d - dist
+			}
+			if isWeekday(d + dist) {
+				return d + dist
+			}
+		}
+		panic("unreachable")
+	}
+	for d := 1; d <= last; d++ {
+		if (c.domW[d] || c.domLW[last-d]) && nearest(d) == dom {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *refCron) matchDow(y int, mon time.Month, dom int, dow time.Weekday) bool {
+	last := time.Date(y, mon+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return c.fields[4][dow] || c.dowN[dow][(dom-1)/7+1] || c.dowL[dow] && dom+7 > last
+}
+
+func (c *refCron) parseDow(field string) bool {
+	for _, group := range strings.Split(field, ",") {
+		switch {
+		case strings.Contains(group, "#"):
+			s := strings.SplitN(group, "#", 2)
+			n, _ := strconv.Atoi(s[1])
+			c.dowN[aliasToNumber(s[0], 4)%7][n] = true
+		case strings.HasSuffix(group, "l"):
+			c.dowL[aliasToNumber(strings.TrimSuffix(group, "l"), 4)%7] = true
+		default:
+			if !parseCronField(c.fields[4][:], group, 4) {
+				return false
+			}
+		}
+	}
+	if c.fields[4][7] {
+		c.fields[4][0] = true
+	}
+	return true
+}
+
 func validCron(expr string) bool {
 	if !cronRe.MatchString(expr) {
 		return false
 	}
 
 	// Detect combinations of impossible month/day pairs.
-	s := strings.SplitN(expr, " ", 5)
+	s := strings.SplitN(expr, " ", 6)
 	var mon [32]bool
 	if !parseCronField(mon[:], s[3], 3) {
 		return false
@@ -379,8 +1739,9 @@ func validCron(expr string) bool {
 	if mon[1] || mon[3] || mon[5] || mon[7] || mon[8] || mon[10] || mon[12] {
 		return true
 	}
-	var dom [32]bool
-	if !parseCronField(dom[:], s[2], 2) {
+	var dom, domW [32]bool
+	var domL, domLW [31]bool
+	if !parseRefDom(dom[:], domL[:], domW[:], domLW[:], s[2]) {
 		return false
 	}
 	maxDays := 29
@@ -388,30 +1749,64 @@ func validCron(expr string) bool {
 		maxDays = 30
 	}
 	for i := 1; i <= maxDays; i++ {
-		if dom[i] {
+		if dom[i] || domL[i-1] || domW[i] || domLW[i-1] {
 			return true
 		}
 	}
 	return false
 }
 
+func parseRefDom(dom, domL, domW, domLW []bool, field string) bool {
+	for _, group := range strings.Split(field, ",") {
+		weekday := strings.HasSuffix(group, "w")
+		group = strings.TrimSuffix(group, "w")
+		switch {
+		case !strings.HasPrefix(group, "l") && weekday:
+			d, _ := strconv.Atoi(group)
+			domW[d] = true
+		case !strings.HasPrefix(group, "l"):
+			if !parseCronField(dom, group, 2) {
+				return false
+			}
+		default:
+			offset := 0
+			if group != "l" {
+				offset, _ = strconv.Atoi(strings.TrimPrefix(group, "l-"))
+			}
+			if weekday {
+				domLW[offset] = true
+			} else {
+				domL[offset] = true
+			}
+		}
+	}
+	return true
+}
+
+var (
+	refFieldMin = [6]int{0, 0, 1, 1, 0, 1970}
+	refFieldMax = [6]int{59, 23, 31, 12, 7, 2199}
+)
+
 func parseCronField(out []bool, field string, fieldIndex int) bool {
 	for _, num := range strings.Split(field, ",") {
 		from, to, step := 0, 0, 1
 
 		switch {
 		case num == "*":
-			from, to, step = 0, len(out)-1, 1
+			from, to, step = refFieldMin[fieldIndex], refFieldMax[fieldIndex], 1
 
 		case strings.ContainsRune(num, '/'):
 			s := strings.SplitN(num, "/", 2)
-			if s0 := s[0]; strings.ContainsRune(s0, '-') {
+			if s0 := s[0]; s0 == "*" {
+				from, to = refFieldMin[fieldIndex], refFieldMax[fieldIndex]
+			} else if strings.ContainsRune(s0, '-') {
 				z := strings.SplitN(s0, "-", 2)
 				from = aliasToNumber(z[0], fieldIndex)
 				to = aliasToNumber(z[1], fieldIndex)
 			} else {
 				from = aliasToNumber(s0, fieldIndex)
-				to = len(out) - 1
+				to = refFieldMax[fieldIndex]
 			}
 			step, _ = strconv.Atoi(s[1])
 
@@ -426,12 +1821,31 @@ func parseCronField(out []bool, field string, fieldIndex int) bool {
 			to, step = from, 1
 		}
 
-		if to < from || from < 0 || step <= 0 {
+		if from < 0 || step <= 0 {
 			return false
 		}
 
-		for i := from; i <= to; i += step {
-			out[i] = true
+		// Wrap around ranges such as 22-2. Days of week wrap around from
+		// Saturday to Sunday, regardless of whether Sunday is 0 or 7.
+		min, max := refFieldMin[fieldIndex], refFieldMax[fieldIndex]
+		if fieldIndex == 4 && to < from {
+			from %= 7
+			max = 6
+		}
+		var values []int
+		for i := from; i <= max; i++ {
+			values = append(values, i)
+		}
+		for i := min; i < from; i++ {
+			values = append(values, i)
+		}
+		for i, v := range values {
+			if i%step == 0 {
+				out[v] = true
+			}
+			if v == to {
+				break
+			}
 		}
 	}
 	return true
@@ -441,46 +1855,46 @@ func aliasToNumber(s string, fieldIndex int) int {
 	switch fieldIndex {
 	case 3: // month
 		switch s {
-		case "jan":
+		case "jan", "january":
 			return 1
-		case "feb":
+		case "feb", "february":
 			return 2
-		case "mar":
+		case "mar", "march":
 			return 3
-		case "apr":
+		case "apr", "april":
 			return 4
 		case "may":
 			return 5
-		case "jun":
+		case "jun", "june":
 			return 6
-		case "jul":
+		case "jul", "july":
 			return 7
-		case "aug":
+		case "aug", "august":
 			return 8
-		case "sep":
+		case "sep", "september":
 			return 9
-		case "oct":
+		case "oct", "october":
 			return 10
-		case "nov":
+		case "nov", "november":
 			return 11
-		case "dec":
+		case "dec", "december":
 			return 12
 		}
 	case 4: // weekday
 		switch s {
-		case "sun":
+		case "sun", "sunday":
 			return 0
-		case "mon":
+		case "mon", "monday":
 			return 1
-		case "tue":
+		case "tue", "tuesday":
 			return 2
-		case "wed":
+		case "wed", "wednesday":
 			return 3
-		case "thu":
+		case "thu", "thursday":
 			return 4
-		case "fri":
+		case "fri", "friday":
 			return 5
-		case "sat":
+		case "sat", "saturday":
 			return 6
 		}
 	}
@@ -0,0 +1,168 @@
+package cron
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Warning is a suspicious part of a valid cron expression, reported by
+// Lint.
+type Warning struct {
+	Kind  WarningKind
+	Field Field
+
+	// Msg explains the warning, e.g., "runs every minute of the matching
+	// hours, 60 times each".
+	Msg string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("field %q: %v: %s", w.Field, w.Kind, w.Msg)
+}
+
+// A WarningKind identifies the kind of a Warning.
+type WarningKind int
+
+const (
+	// WarningUnevenStep is a step that does not divide the range of its
+	// field, so that the gap across the end of the range differs from the
+	// others, e.g., "*/7" in the minutes field runs at 56 and then at 0, 4
+	// minutes apart.
+	WarningUnevenStep WarningKind = iota
+
+	// WarningWildcard is "*" in the minutes or seconds field while a larger
+	// unit is restricted, e.g., "* 3 * * *", which runs 60 times from 03:00
+	// to 03:59, rather than once at 03:00.
+	WarningWildcard
+
+	// WarningSkippedMonths is a days of month field that matches no day in
+	// some of the months, which are then skipped, e.g., "31", which skips
+	// February, April, June, September and November.
+	WarningSkippedMonths
+
+	// WarningDaysAnd is both days fields restricted while a day must match
+	// both, e.g., "0 0 13 * fri" runs on Fridays the 13th only, whereas
+	// POSIX cron runs it on every 13th and every Friday. See WithDaysOr. It
+	// is not reported if either field starts with "*", e.g., "*/2", since
+	// POSIX cron then matches both fields too.
+	WarningDaysAnd
+
+	// WarningSixFields is a years field matching every year without a
+	// seconds field, likely a seconds field shifting the others, e.g.,
+	// "0 */5 * * * *" runs every 5 hours, rather than every 5 minutes. It is
+	// not reported under DialectEventBridge, which requires a years field.
+	WarningSixFields
+)
+
+// String returns the name of the kind.
+func (k WarningKind) String() string {
+	switch k {
+	case WarningUnevenStep:
+		return "uneven step"
+	case WarningWildcard:
+		return "wildcard"
+	case WarningSkippedMonths:
+		return "skipped months"
+	case WarningDaysAnd:
+		return "days and"
+	case WarningSixFields:
+		return "six fields"
+	default:
+		return strconv.FormatInt(int64(k), 10)
+	}
+}
+
+// Lint parses expr as Parse does, and returns warnings about parts of it
+// that are valid, but likely mistakes, in the order of the fields.
+func Lint(expr string, opts ...Option) ([]Warning, error) {
+	p := NewParser(opts...)
+	e, err := p.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	warnings := e.lint()
+	if !e.seconds && !p.opts.eventBridge && e.y.isFull() {
+		warnings = append(warnings, Warning{
+			Kind: WarningSixFields, Field: FieldYears,
+			Msg: "matches every year; for a leading seconds field, parse with WithSeconds",
+		})
+	}
+	return warnings, nil
+}
+
+func (e *Expr) lint() (warnings []Warning) {
+	if e.every != 0 {
+		return nil
+	}
+	warn := func(kind WarningKind, typ Field, format string, args ...interface{}) {
+		warnings = append(warnings, Warning{Kind: kind, Field: typ, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	const allHours = uint32(1<<24 - 1)
+	allMinutes := uint64(1<<60 - 1)
+
+	if e.seconds {
+		if step, gap, last, ok := unevenStep(e.s, 0, 59); ok {
+			warn(WarningUnevenStep, FieldSeconds, "runs every %d seconds, but %d seconds apart from second %d to 0", step, gap, last)
+		}
+		if e.s == allMinutes && (e.m != allMinutes || e.h != allHours) {
+			warn(WarningWildcard, FieldSeconds, "runs every second of the matching minutes, 60 times each")
+		}
+	}
+	if step, gap, last, ok := unevenStep(e.m, 0, 59); ok {
+		warn(WarningUnevenStep, FieldMinutes, "runs every %d minutes, but %d minutes apart from minute %d to 0", step, gap, last)
+	}
+	if e.m == allMinutes && e.h != allHours {
+		warn(WarningWildcard, FieldMinutes, "runs every minute of the matching hours, 60 times each")
+	}
+	if step, gap, last, ok := unevenStep(uint64(e.h), 0, 23); ok {
+		warn(WarningUnevenStep, FieldHours, "runs every %d hours, but %d hours apart from %02d:00 to 00:00", step, gap, last)
+	}
+
+	if skipped := e.daylessMonths(); skipped != 0 {
+		var names []string
+		for m := skipped; m != 0; m &= m - 1 {
+			names = append(names, time.Month(bits.TrailingZeros16(m)).String())
+		}
+		warn(WarningSkippedMonths, FieldDaysOfMonth, "matches no day in %s, which are skipped", strings.Join(names, ", "))
+	}
+
+	if step, gap, last, ok := unevenStep(uint64(e.mon), 1, 12); ok {
+		warn(WarningUnevenStep, FieldMonths, "runs every %d months, but %d months apart from %v to January", step, gap, time.Month(last))
+	}
+	if step, gap, last, ok := unevenStep(uint64(e.dow), 0, 6); ok {
+		apart := strconv.Itoa(gap) + " days"
+		if gap == 1 {
+			apart = "1 day"
+		}
+		warn(WarningUnevenStep, FieldDaysOfWeek, "runs every %d days, but %s apart from %v to Sunday", step, apart, time.Weekday(last))
+	}
+	if !e.domAll() && !e.dowAll() && !e.daysOr && !e.wildcardDays {
+		warn(WarningDaysAnd, FieldDaysOfWeek, "matches days that match both days fields, not either of them as in POSIX cron")
+	}
+	return warnings
+}
+
+// unevenStep reports whether the bits of field between min and max are
+// evenly spaced from the start to the end of the range, at least three of
+// them, with a step that does not divide the size of the range. If so, it
+// returns the step, the gap between the last bit and the first one across the
+// end of the range, and the last bit.
+func unevenStep(field uint64, min, max int) (step, gap, last int, ok bool) {
+	n := bits.OnesCount64(field)
+	if n < 3 {
+		return 0, 0, 0, false
+	}
+	first := bits.TrailingZeros64(field)
+	last = 63 - bits.LeadingZeros64(field)
+	step = (last - first) / (n - 1)
+	if rangeBits(first, last, step, min, max) != field {
+		return 0, 0, 0, false
+	}
+	size := max - min + 1
+	gap = size - (last - first)
+	return step, gap, last, first-min < step && last+step > max && gap != step
+}
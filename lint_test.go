@@ -0,0 +1,77 @@
+package cron_test
+
+import (
+	"reflect"
+	"testing"
+
+	"fmrsn.com/cron"
+)
+
+func TestLint(t *testing.T) {
+	type warning struct {
+		kind  cron.WarningKind
+		field cron.Field
+	}
+	tests := []struct {
+		expr string
+		opts []cron.Option
+		want []warning
+	}{
+		{"0 0 * * *", nil, nil},
+		{"*/5 * * * *", nil, nil},
+		{"* * * * *", nil, nil},
+		{"*/15 9-17 * * mon-fri", nil, nil},
+		{"0-30/7 * * * *", nil, nil},
+		{"10-50/10 * * * *", nil, nil},
+		{"0 0 1 */3 *", nil, nil},
+		{"@every 7m", nil, nil},
+		{"*/7 * * * *", nil, []warning{{cron.WarningUnevenStep, cron.FieldMinutes}}},
+		{"3/7 * * * *", nil, []warning{{cron.WarningUnevenStep, cron.FieldMinutes}}},
+		{"0 */5 * * *", nil, []warning{{cron.WarningUnevenStep, cron.FieldHours}}},
+		{"0 0 1 */5 *", nil, []warning{{cron.WarningUnevenStep, cron.FieldMonths}}},
+		{"0 0 * * */2", nil, []warning{{cron.WarningUnevenStep, cron.FieldDaysOfWeek}}},
+		{"*/9 * * * * *", []cron.Option{cron.WithSeconds()}, []warning{{cron.WarningUnevenStep, cron.FieldSeconds}}},
+		{"* 3 * * *", nil, []warning{{cron.WarningWildcard, cron.FieldMinutes}}},
+		{"* 0 * * * *", []cron.Option{cron.WithSeconds()}, []warning{{cron.WarningWildcard, cron.FieldSeconds}}},
+		{"* * 3 * * *", []cron.Option{cron.WithSeconds()}, []warning{
+			{cron.WarningWildcard, cron.FieldSeconds}, {cron.WarningWildcard, cron.FieldMinutes},
+		}},
+		{"0 0 31 * *", nil, []warning{{cron.WarningSkippedMonths, cron.FieldDaysOfMonth}}},
+		{"0 0 30 1-3 *", nil, []warning{{cron.WarningSkippedMonths, cron.FieldDaysOfMonth}}},
+		{"0 0 L-29 * *", nil, []warning{{cron.WarningSkippedMonths, cron.FieldDaysOfMonth}}},
+		{"0 0 29 2 *", nil, nil},
+		{"0 0 31 * mon", []cron.Option{cron.WithDaysOr()}, nil},
+		{"0 0 13 * fri", nil, []warning{{cron.WarningDaysAnd, cron.FieldDaysOfWeek}}},
+		{"0 0 L * 5L", nil, []warning{{cron.WarningDaysAnd, cron.FieldDaysOfWeek}}},
+		{"0 0 */2 * mon", nil, nil},
+		{"0 0 1-31/2 * mon", nil, []warning{{cron.WarningDaysAnd, cron.FieldDaysOfWeek}}},
+		{"0 0 1,15 * mon", []cron.Option{cron.WithDaysOr()}, nil},
+		{"0 */6 * * * *", nil, []warning{{cron.WarningSixFields, cron.FieldYears}}},
+		{"0 */6 * * * *", []cron.Option{cron.WithSeconds()}, nil},
+		{"0 0 * * * 2030", nil, nil},
+		{"cron(0 10 * * ? *)", []cron.Option{cron.WithDialect(cron.DialectEventBridge)}, nil},
+		{"* */5 31 * *", nil, []warning{
+			{cron.WarningWildcard, cron.FieldMinutes},
+			{cron.WarningUnevenStep, cron.FieldHours},
+			{cron.WarningSkippedMonths, cron.FieldDaysOfMonth},
+		}},
+	}
+	for _, tt := range tests {
+		warnings, err := cron.Lint(tt.expr, tt.opts...)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		var got []warning
+		for _, w := range warnings {
+			got = append(got, warning{w.Kind, w.Field})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: wrong warnings\ngot:  %v\nwant: %v", tt.expr, warnings, tt.want)
+		}
+	}
+
+	if _, err := cron.Lint("0 0 30 2 *"); err == nil {
+		t.Errorf("expected Lint to reject %q", "0 0 30 2 *")
+	}
+}
@@ -0,0 +1,394 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Render returns e in the syntax of the dialect d, or an error if d cannot
+// express e. Unlike String, which returns the text e was parsed from, Render
+// builds the result from the fields of e, so that it can convert expressions
+// between dialects. For DialectDefault, it returns the canonical form of e.
+func (e *Expr) Render(d Dialect) (s string, err error) {
+	switch d {
+	case DialectDefault:
+		s = e.Canonical()
+	case DialectKubernetes:
+		s, err = e.renderKubernetes()
+	case DialectQuartz:
+		s, err = e.renderQuartz()
+	case DialectEventBridge:
+		s, err = e.renderEventBridge()
+	case DialectSystemd:
+		s, err = e.renderSystemd()
+	default:
+		err = errors.New("dialect not supported")
+	}
+	if err != nil {
+		return "", fmt.Errorf("cron: rendering %q as %v: %v", e.String(), d, err)
+	}
+	return s, nil
+}
+
+// Canonical returns the shortest expression equivalent to e in the syntax of
+// Parse, built from its fields rather than from the text e was parsed from:
+// "*" for whole fields, ranges, including ones that wrap around, and steps
+// where possible, and lowercase names for months and days of week, e.g.,
+// "* * * * *" for "0-59/1 * * * *", or "sat-mon" for "0,1,6". It parses back
+// to an expression equivalent to e, given WithSeconds if e has a seconds
+// field, and WithDaysOr if e matches days in either days field. Canonical
+// returns "" for the zero Expr.
+func (e *Expr) Canonical() string {
+	if e.every != 0 {
+		return "@every " + e.every.String()
+	}
+	if e.mon == 0 {
+		return ""
+	}
+
+	var fields []string
+	if e.loc != nil {
+		fields = append(fields, "TZ="+e.loc.String())
+	}
+	if e.seconds {
+		fields = append(fields, formatShortest(e.s, 0, 59, nil, false))
+	}
+	dom, dow := e.formatDom(), e.formatDow()
+	if e.daysOr {
+		// Days match if either field matches only if neither starts with
+		// "*".
+		if strings.HasPrefix(dom, "*") {
+			dom = "1-31" + dom[1:]
+		}
+		if strings.HasPrefix(dow, "*") {
+			dow = "sun-sat" + dow[1:]
+		}
+	}
+	fields = append(fields,
+		formatShortest(e.m, 0, 59, nil, false), formatShortest(uint64(e.h), 0, 23, nil, false), dom,
+		formatShortest(uint64(e.mon), 1, 12, monNames[:], false), dow)
+	if !e.y.isEmpty() {
+		fields = append(fields, e.formatYears())
+	}
+	return strings.Join(fields, " ")
+}
+
+// formatDom returns the days of month field of e, including its L and W
+// groups.
+func (e *Expr) formatDom() string {
+	if e.dom == allDom {
+		return "*"
+	}
+	var groups []string
+	if e.dom != 0 {
+		groups = append(groups, formatShortest(uint64(e.dom), 1, 31, nil, false))
+	}
+	for d := e.domW; d != 0; d &= d - 1 {
+		groups = append(groups, strconv.Itoa(bits.TrailingZeros32(d))+"W")
+	}
+	for _, l := range [...]struct {
+		field  uint32
+		suffix string
+	}{{e.domL, ""}, {e.domLW, "W"}} {
+		for d := l.field; d != 0; d &= d - 1 {
+			if offset := bits.TrailingZeros32(d); offset == 0 {
+				groups = append(groups, "L"+l.suffix)
+			} else {
+				groups = append(groups, "L-"+strconv.Itoa(offset)+l.suffix)
+			}
+		}
+	}
+	return strings.Join(groups, ",")
+}
+
+// formatDow returns the days of week field of e, including its # and L
+// groups, with days of week as names.
+func (e *Expr) formatDow() string {
+	if e.dow == allDow {
+		return "*"
+	}
+	var groups []string
+	if e.dow != 0 {
+		// Parse reads days of week up to 7, which is Sunday again, so steps
+		// must end at the last day, e.g., "mon/2" would match Sunday too.
+		groups = append(groups, formatShortest(uint64(e.dow), 0, 6, dowNames[:], true))
+	}
+	for i, n := range e.dowN {
+		for d := n; d != 0; d &= d - 1 {
+			groups = append(groups, fmt.Sprintf("%s#%d", dowNames[bits.TrailingZeros8(d)][:3], i+1))
+		}
+	}
+	for d := e.dowL; d != 0; d &= d - 1 {
+		groups = append(groups, dowNames[bits.TrailingZeros8(d)][:3]+"L")
+	}
+	return strings.Join(groups, ",")
+}
+
+// formatShortest is like formatField, but also tries joining the groups that
+// end at max and start at min into a range that wraps around, e.g., hours
+// "22-2" rather than "0-2,22,23", and returns the shorter result, with names
+// as in formatNames if given. If closed, it writes an open-ended step from
+// a value, e.g., "1/2", as a range, "1-5/2".
+func formatShortest(field uint64, min, max int, names []string, closed bool) string {
+	format := func(values []int) string {
+		groups := formatValues(values, min, max)
+		if from, step, ok := strings.Cut(groups, "/"); ok && closed && from != "*" && !strings.Contains(from, "-") {
+			groups = from + "-" + strconv.Itoa(values[len(values)-1]) + "/" + step
+		}
+		return groups
+	}
+	named := func(groups string) string {
+		if names == nil {
+			return groups
+		}
+		return formatNames(groups, min, names)
+	}
+
+	var values []int
+	for f := field; f != 0; f &= f - 1 {
+		values = append(values, bits.TrailingZeros64(f))
+	}
+	groups := named(format(values))
+	n := len(values)
+	if n == 0 || n == max-min+1 || values[0] != min || values[n-1] != max {
+		return groups
+	}
+	i, j := 0, n-1 // values[:i+1] start at min, and values[j:] end at max.
+	for values[i+1] == values[i]+1 {
+		i++
+	}
+	for j-1 > i && values[j-1] == values[j]-1 {
+		j--
+	}
+	wrapped := strconv.Itoa(values[j]) + "-" + strconv.Itoa(values[i])
+	if i+1 < j {
+		wrapped = format(values[i+1:j]) + "," + wrapped
+	}
+	if wrapped = named(wrapped); len(wrapped) < len(groups) {
+		return wrapped
+	}
+	return groups
+}
+
+// formatNames replaces the numbers of a list of groups, but not its steps,
+// with the first three letters of names, where names[0] stands for min, e.g.,
+// "1-5/2" with "jan-may/2".
+func formatNames(groups string, min int, names []string) string {
+	list := strings.Split(groups, ",")
+	for i, group := range list {
+		r, step, hasStep := strings.Cut(group, "/")
+		if r != "*" {
+			from, to, isRange := strings.Cut(r, "-")
+			n, _ := strconv.Atoi(from)
+			r = names[n-min][:3]
+			if isRange {
+				n, _ = strconv.Atoi(to)
+				r += "-" + names[n-min][:3]
+			}
+		}
+		if hasStep {
+			r += "/" + step
+		}
+		list[i] = r
+	}
+	return strings.Join(list, ",")
+}
+
+// renderKubernetes implements Render for DialectKubernetes.
+func (e *Expr) renderKubernetes() (string, error) {
+	if e.every != 0 {
+		return "@every " + e.every.String(), nil
+	}
+	if e.loc != nil {
+		return "", errors.New("time zones not supported; use the timeZone field instead")
+	}
+	if e.s != 1 {
+		return "", errors.New("seconds not supported")
+	}
+	if !e.y.isEmpty() {
+		return "", errors.New("years not supported")
+	}
+	if e.domL|e.domW|e.domLW != 0 || e.dowN != [5]uint8{} || e.dowL != 0 {
+		return "", errors.New("L, W and # not supported")
+	}
+
+	// Kubernetes matches days if either days field matches, unless one of
+	// them is "*".
+	dom, dow := formatField(uint64(e.dom), 1, 31), formatField(uint64(e.dow), 0, 6)
+	if dom != "*" && dow != "*" && !e.daysOr {
+		return "", errors.New("both days of month and days of week not supported")
+	}
+	if e.daysOr && (dom == "*" || dow == "*") {
+		dom, dow = "*", "*"
+	}
+	return strings.Join([]string{
+		formatField(e.m, 0, 59), formatField(uint64(e.h), 0, 23), dom,
+		formatField(uint64(e.mon), 1, 12), dow,
+	}, " "), nil
+}
+
+// renderQuartz implements Render for DialectQuartz.
+func (e *Expr) renderQuartz() (string, error) {
+	if e.every != 0 {
+		return "", errors.New("intervals not supported")
+	}
+	if e.loc != nil {
+		return "", errors.New("time zones not supported")
+	}
+	if !e.y.isEmpty() && e.y.next(2099) <= maxYear {
+		return "", errors.New("years after 2099 not supported")
+	}
+	dom, dow, err := e.formatQuartzDays()
+	if err != nil {
+		return "", err
+	}
+	fields := []string{
+		formatField(e.s, 0, 59), formatField(e.m, 0, 59), formatField(uint64(e.h), 0, 23), dom,
+		formatField(uint64(e.mon), 1, 12), dow,
+	}
+	if !e.y.isEmpty() {
+		fields = append(fields, e.formatYears())
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// formatField returns a list of groups that sets the bits of field between min
+// and max: "*" if all of them are set, a single range or step if possible, or
+// else a list of numbers and ranges.
+func formatField(field uint64, min, max int) string {
+	var values []int
+	for f := field; f != 0; f &= f - 1 {
+		values = append(values, bits.TrailingZeros64(f))
+	}
+	return formatValues(values, min, max)
+}
+
+// formatValues is like formatField, but takes the values in increasing order.
+func formatValues(values []int, min, max int) string {
+	n := len(values)
+	switch {
+	case n == 0:
+		return ""
+	case n == max-min+1:
+		return "*"
+	case n == 1:
+		return strconv.Itoa(values[0])
+	}
+
+	step := values[1] - values[0]
+	for i := 2; i < n; i++ {
+		if values[i]-values[i-1] != step {
+			step = 0
+			break
+		}
+	}
+	first, last := values[0], values[n-1]
+	switch {
+	case step > 1 && n > 2 && last+step > max && first == min:
+		return "*/" + strconv.Itoa(step)
+	case step > 1 && n > 2 && last+step > max:
+		return fmt.Sprintf("%d/%d", first, step)
+	case step > 1 && n > 2:
+		return fmt.Sprintf("%d-%d/%d", first, last, step)
+	}
+
+	var b strings.Builder
+	for i := 0; i < n; {
+		j := i
+		for j+1 < n && values[j+1] == values[j]+1 {
+			j++
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(values[i]))
+		switch {
+		case j == i+1:
+			b.WriteByte(',')
+			b.WriteString(strconv.Itoa(values[j]))
+		case j > i+1:
+			b.WriteByte('-')
+			b.WriteString(strconv.Itoa(values[j]))
+		}
+		i = j + 1
+	}
+	return b.String()
+}
+
+// formatYears returns the years field of e, which is "*" if e has none.
+func (e *Expr) formatYears() string {
+	if e.y.isEmpty() {
+		return "*"
+	}
+	var values []int
+	for y := minYear; y <= maxYear; y++ {
+		if e.y.has(y) {
+			values = append(values, y)
+		}
+	}
+	return formatValues(values, minYear, maxYear)
+}
+
+// formatQuartzDays returns the days fields of e in the Quartz syntax, where
+// one of them must be "?", and days of week range from 1 (Sunday) to 7.
+func (e *Expr) formatQuartzDays() (dom, dow string, err error) {
+	domAll, dowAll := e.domAll(), e.dowAll()
+	switch {
+	case domAll && dowAll, e.daysOr && (domAll || dowAll):
+		return "*", "?", nil
+	case e.daysOr:
+		return "", "", errors.New("days of month or days of week not supported")
+	case dowAll:
+		dom, err = e.formatQuartzDom()
+		return dom, "?", err
+	case domAll:
+		dow, err = e.formatQuartzDow()
+		return "?", dow, err
+	default:
+		return "", "", errors.New("both days of month and days of week not supported")
+	}
+}
+
+func (e *Expr) formatQuartzDom() (string, error) {
+	modifiers := bits.OnesCount32(e.domL) + bits.OnesCount32(e.domW) + bits.OnesCount32(e.domLW)
+	switch {
+	case modifiers == 0:
+		return formatField(uint64(e.dom), 1, 31), nil
+	case modifiers > 1 || e.dom != 0:
+		return "", errors.New("L or W with other days not supported")
+	case e.domW != 0:
+		return strconv.Itoa(bits.TrailingZeros32(e.domW)) + "W", nil
+	case e.domL == 1:
+		return "L", nil
+	case e.domL != 0:
+		return "L-" + strconv.Itoa(bits.TrailingZeros32(e.domL)), nil
+	case e.domLW == 1:
+		return "LW", nil
+	default:
+		return "L-" + strconv.Itoa(bits.TrailingZeros32(e.domLW)) + "W", nil
+	}
+}
+
+func (e *Expr) formatQuartzDow() (string, error) {
+	modifiers := bits.OnesCount8(e.dowL)
+	nth := -1
+	for i, n := range e.dowN {
+		if n != 0 {
+			modifiers += bits.OnesCount8(n)
+			nth = i
+		}
+	}
+	switch {
+	case modifiers == 0:
+		return formatField(uint64(e.dow)<<1, 1, 7), nil
+	case modifiers > 1 || e.dow != 0:
+		return "", errors.New("L or # with other days not supported")
+	case e.dowL != 0:
+		return strconv.Itoa(bits.TrailingZeros8(e.dowL)+1) + "L", nil
+	default:
+		return fmt.Sprintf("%d#%d", bits.TrailingZeros8(e.dowN[nth])+1, nth+1), nil
+	}
+}
@@ -0,0 +1,163 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"fmrsn.com/cron"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		expr    string
+		opts    []cron.Option
+		dialect cron.Dialect
+		want    string
+	}{
+		{"0 12 * * *", nil, cron.DialectKubernetes, "0 12 * * *"},
+		{"*/15 9-17 * * mon-fri", nil, cron.DialectKubernetes, "*/15 9-17 * * 1-5"},
+		{"0 0 1,15 * sun", []cron.Option{cron.WithDaysOr()}, cron.DialectKubernetes, "0 0 1,15 * 0"},
+		{"0 0 * * sun", []cron.Option{cron.WithDaysOr()}, cron.DialectKubernetes, "0 0 * * 0"},
+		{"0 0 1-7 * 7", nil, cron.DialectKubernetes, ""},
+		{"0 0 L * *", nil, cron.DialectKubernetes, ""},
+		{"0 0 1 1 * 2030", nil, cron.DialectKubernetes, ""},
+		{"30 0 0 * * *", []cron.Option{cron.WithSeconds()}, cron.DialectKubernetes, ""},
+		{"TZ=UTC 0 0 * * *", nil, cron.DialectKubernetes, ""},
+		{"@every 1h30m", nil, cron.DialectKubernetes, "@every 1h30m0s"},
+
+		{"0 12 * * *", nil, cron.DialectQuartz, "0 0 12 * * ?"},
+		{"30 */15 9-17 * * mon-fri", []cron.Option{cron.WithSeconds()}, cron.DialectQuartz, "30 */15 9-17 ? * 2-6"},
+		{"0 0 L-2 * *", nil, cron.DialectQuartz, "0 0 0 L-2 * ?"},
+		{"0 0 * * 5#2", nil, cron.DialectQuartz, "0 0 0 ? * 6#2"},
+		{"0 0 1 1 * 2030-2040", nil, cron.DialectQuartz, "0 0 0 1 1 ? 2030-2040"},
+		{"0 0 1 1 * 2100", nil, cron.DialectQuartz, ""},
+		{"0 0 1 * mon", nil, cron.DialectQuartz, ""},
+		{"TZ=Europe/Berlin 0 0 * * *", nil, cron.DialectQuartz, ""},
+		{"@every 5m", nil, cron.DialectQuartz, ""},
+
+		{"0 12 * * *", nil, cron.DialectSystemd, "*-*-* 12:00:00"},
+		{"*/15 9-17 * * mon-fri", nil, cron.DialectSystemd, "Mon..Fri *-*-* 09..17:00/15:00"},
+		{"0 0 * * sat,sun,mon", nil, cron.DialectSystemd, "Mon,Sat..Sun *-*-* 00:00:00"},
+		{"0 6 1,15 2-12/2 *", nil, cron.DialectSystemd, "*-02/2-01,15 06:00:00"},
+		{"0 0 L * *", nil, cron.DialectSystemd, "*-*~01 00:00:00"},
+		{"0 0 L-2 * *", nil, cron.DialectSystemd, "*-*~03 00:00:00"},
+		{"0 0 L,L-7,L-14 * *", nil, cron.DialectSystemd, "*-*~15/7 00:00:00"},
+		{"0 0 L-1,L-2 * *", nil, cron.DialectSystemd, ""},
+		{"0 0 L,L-1,L-3 * *", nil, cron.DialectSystemd, ""},
+		{"0 0 L,L-1,L-2 * *", nil, cron.DialectSystemd, "*-*~03/1 00:00:00"},
+		{"0 0 * * fri#2", nil, cron.DialectSystemd, "Fri *-*-08..14 00:00:00"},
+		{"0 0 * * fri#5", nil, cron.DialectSystemd, "Fri *-*-29..31 00:00:00"},
+		{"0 0 * * 1L", nil, cron.DialectSystemd, "Mon *-*~07/1 00:00:00"},
+		{"0 0 15W * *", nil, cron.DialectSystemd, ""},
+		{"0 0 1 1 * 2030", nil, cron.DialectSystemd, "2030-01-01 00:00:00"},
+		{"TZ=Europe/Berlin 0 0 * * *", nil, cron.DialectSystemd, "*-*-* 00:00:00 Europe/Berlin"},
+		{"0 0 1 * mon", []cron.Option{cron.WithDaysOr()}, cron.DialectSystemd, ""},
+		{"0 0/5 14,18 ? * MON-FRI *", []cron.Option{cron.WithDialect(cron.DialectQuartz)}, cron.DialectSystemd, "Mon..Fri *-*-* 14,18:00/5:00"},
+		{"@every 5m", nil, cron.DialectSystemd, ""},
+
+		{"0-59/1 */1 * * 1-5", nil, cron.DialectDefault, "* * * * mon-fri"},
+		{"0 12 * * *", nil, cron.DialectPOSIX, ""},
+	}
+	for _, tt := range tests {
+		expr := cron.MustParse(tt.expr, tt.opts...)
+		got, err := expr.Render(tt.dialect)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("expected Render to reject %q as %v, got %q", tt.expr, tt.dialect, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("wrong rendering of %q as %v\ngot:  %q\nwant: %q", tt.expr, tt.dialect, got, tt.want)
+			continue
+		}
+
+		// The rendered expression activates at the same times.
+		rendered, err := cron.NewParser(cron.WithDialect(tt.dialect)).Parse(got)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		from, want := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 10; i++ {
+			if from, want = rendered.Next(from), expr.Next(want); !from.Equal(want) {
+				t.Errorf("%q and %q disagree\ngot:  %v\nwant: %v", tt.expr, got, from, want)
+				break
+			}
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		expr    string
+		opts    []cron.Option
+		want    string
+		reparse []cron.Option
+	}{
+		{"0-59/1 * * * *", nil, "* * * * *", nil},
+		{"0-59 0-23 1-31 1-12 0-6", nil, "* * * * *", nil},
+		{"0,15,30,45 */1 * * *", nil, "*/15 * * * *", nil},
+		{"5-59/10 9,10,11,12 * * *", nil, "5/10 9-12 * * *", nil},
+		{"0 0 * JAN,Feb,3 MON-FRI", nil, "0 0 * jan-mar mon-fri", nil},
+		{"0 0 * * 1,2,3,5", nil, "0 0 * * mon-wed,fri", nil},
+		{"0 0 * * sat-mon", nil, "0 0 * * sat-mon", nil},
+		{"0 0 * * 0,6", nil, "0 0 * * sun,sat", nil},
+		{"0 0 * * 1,3,5", nil, "0 0 * * mon-fri/2", nil},
+		{"0 0 * * 2,4,6", nil, "0 0 * * tue-sat/2", nil},
+		{"0 0 * * 0,2,4,6", nil, "0 0 * * */2", nil},
+		{"0 22-2 * * *", nil, "0 22-2 * * *", nil},
+		{"0 0,1,2,10,22,23 * * *", nil, "0 10,22-2 * * *", nil},
+		{"0 0 * nov-feb *", nil, "0 0 * nov-feb *", nil},
+		{"0 0 28-3 * *", nil, "0 0 28-3 * *", nil},
+		{"0 0 * * 7", nil, "0 0 * * sun", nil},
+		{"0 0 * 1-11/2 *", nil, "0 0 * */2 *", nil},
+		{"0 0 * 2-12/2 *", nil, "0 0 * feb/2 *", nil},
+		{"0 0 * 2-8/3 *", nil, "0 0 * feb-aug/3 *", nil},
+		{"0 0 1,L,L-2,15W,LW * *", nil, "0 0 1,15W,L,L-2,LW * *", nil},
+		{"0 0 * * 5#3,0L", nil, "0 0 * * fri#3,sunL", nil},
+		{"@daily", nil, "0 0 * * *", nil},
+		{"@every 1h30m", nil, "@every 1h30m0s", nil},
+		{"0 0 1 1 * 2020,2021,2022", nil, "0 0 1 jan * 2020-2022", nil},
+		{"TZ=Europe/Berlin 0 9 * * *", nil, "TZ=Europe/Berlin 0 9 * * *", nil},
+		{"*/20 * * * * *", []cron.Option{cron.WithSeconds()}, "*/20 * * * * *", []cron.Option{cron.WithSeconds()}},
+		{"0 0 1,15 * mon", []cron.Option{cron.WithDaysOr()}, "0 0 1,15 * mon", []cron.Option{cron.WithDaysOr()}},
+		{"0 0 1-31 * mon", []cron.Option{cron.WithDaysOr()}, "0 0 1-31 * mon", []cron.Option{cron.WithDaysOr()}},
+		{"0 0 1 * 0-6", []cron.Option{cron.WithDaysOr()}, "0 0 1 * sun-sat", []cron.Option{cron.WithDaysOr()}},
+		{"0 0 * * mon", []cron.Option{cron.WithDaysOr()}, "0 0 * * mon", []cron.Option{cron.WithDaysOr()}},
+		{"0 0 0 ? * 2-6", []cron.Option{cron.WithDialect(cron.DialectQuartz)}, "0 0 0 * * mon-fri", []cron.Option{cron.WithSeconds()}},
+		{"Mon *-*-01..07 09:30", []cron.Option{cron.WithDialect(cron.DialectSystemd)}, "0 30 9 1-7 * mon", []cron.Option{cron.WithSeconds()}},
+		{"0 0/5 14,18 ? * MON-FRI *", []cron.Option{cron.WithDialect(cron.DialectQuartz)}, "0 */5 14,18 * * mon-fri", []cron.Option{cron.WithSeconds()}},
+	}
+	for _, tt := range tests {
+		expr := cron.MustParse(tt.expr, tt.opts...)
+		if got := expr.Canonical(); got != tt.want {
+			t.Errorf("wrong canonical form of %q\ngot:  %q\nwant: %q", tt.expr, got, tt.want)
+			continue
+		}
+
+		// The canonical form parses to an expression that activates at the
+		// same times.
+		canonical, err := cron.Parse(tt.want, tt.reparse...)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		from, want := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 10; i++ {
+			if from, want = canonical.Next(from), expr.Next(want); !from.Equal(want) {
+				t.Errorf("%q and %q disagree\ngot:  %v\nwant: %v", tt.expr, tt.want, from, want)
+				break
+			}
+		}
+	}
+
+	var zero cron.Expr
+	if got := zero.String(); got != "" {
+		t.Errorf("expected an empty string for the zero Expr, got %q", got)
+	}
+}
@@ -0,0 +1,301 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+var systemdShorthands = [...]struct{ name, event string }{
+	{"minutely", "*-*-* *:*:00"},
+	{"hourly", "*-*-* *:00:00"},
+	{"daily", "*-*-* 00:00:00"},
+	{"weekly", "Mon *-*-* 00:00:00"},
+	{"monthly", "*-*-01 00:00:00"},
+	{"quarterly", "*-01,04,07,10-01 00:00:00"},
+	{"semiannually", "*-01,07-01 00:00:00"},
+	{"yearly", "*-01-01 00:00:00"},
+	{"annually", "*-01-01 00:00:00"},
+}
+
+/*
+cutSystemd translates a systemd calendar event into the fields of the
+equivalent cron expression with seconds, and years if restricted, and returns
+its time zone, if any. Calendar events implement the following BNF, where
+weekdays are English names:
+
+	event      ::= shorthand ( ' ' zone )? | weekdays? ' '? date? ' '? time? ( ' ' zone )?
+	weekdays   ::= weekday ( ( '..' | ',' ) weekday )*
+	date       ::= ( year '-' )? month ( '-' day | '~' lastDay )
+	time       ::= hour ':' minute ( ':' second )?
+	lastDay    ::= number ( '/' number )?
+
+Other components are lists of values, ranges "a..b" and repetitions "a/n"
+like those of cron, where a tilde before the day counts from the end of the
+month, e.g., "*-02~01" is the last day of February.
+*/
+func cutSystemd(event string) (fields, zone string, err error) {
+	tokens := strings.Fields(event)
+	if len(tokens) == 0 {
+		return "", "", errors.New("empty calendar event")
+	}
+	if n := len(tokens); n > 1 && !strings.Contains(tokens[n-1], ":") && !isSystemdNumeric(tokens[n-1][0]) {
+		zone, tokens = tokens[n-1], tokens[:n-1]
+	}
+	for _, s := range systemdShorthands {
+		if len(tokens) == 1 && strings.EqualFold(tokens[0], s.name) {
+			tokens = strings.Fields(s.event)
+			break
+		}
+	}
+
+	weekdays, date, clock := "*", "*-*-*", "00:00:00"
+	if t := tokens[0]; !isSystemdNumeric(t[0]) {
+		weekdays, tokens = strings.ReplaceAll(t, "..", "-"), tokens[1:]
+		for _, group := range strings.Split(weekdays, ",") {
+			from, to, _ := strings.Cut(group, "-")
+			if _, ok := dowFromName(from); !ok {
+				return "", "", fmt.Errorf("malformed weekdays %q", t)
+			}
+			if _, ok := dowFromName(to); !ok && to != "" {
+				return "", "", fmt.Errorf("malformed weekdays %q", t)
+			}
+		}
+	}
+	if len(tokens) > 0 && !strings.Contains(tokens[0], ":") {
+		date, tokens = tokens[0], tokens[1:]
+	}
+	if len(tokens) > 0 {
+		clock, tokens = tokens[0], tokens[1:]
+	}
+	if len(tokens) > 0 {
+		return "", "", fmt.Errorf("unexpected %q", tokens[0])
+	}
+
+	year, month, day, err := splitSystemdDate(date)
+	if err != nil {
+		return "", "", err
+	}
+	h, m, found := strings.Cut(clock, ":")
+	if !found {
+		return "", "", fmt.Errorf("malformed time %q", clock)
+	}
+	m, s, found := strings.Cut(m, ":")
+	if !found {
+		s = "00"
+	}
+	for _, c := range [...]string{year, month, day, h, m, s} {
+		if c == "" || strings.Trim(c, "0123456789*,./~") != "" {
+			return "", "", fmt.Errorf("malformed component %q", c)
+		}
+	}
+
+	if strings.HasPrefix(day, "~") {
+		if day, err = systemdLastDays(day[1:]); err != nil {
+			return "", "", err
+		}
+	}
+	fields = strings.Join([]string{s, m, h, day, month, weekdays}, " ")
+	if year != "*" {
+		fields += " " + year
+	}
+	return strings.ReplaceAll(fields, "..", "-"), zone, nil
+}
+
+// isSystemdNumeric reports whether a component of a calendar event starting
+// with c is made of numbers rather than names.
+func isSystemdNumeric(c byte) bool {
+	return c >= '0' && c <= '9' || c == '*' || c == '~'
+}
+
+// splitSystemdDate returns the components of a date, where the day starts with
+// a tilde if it counts from the end of the month.
+func splitSystemdDate(date string) (year, month, day string, err error) {
+	if before, after, found := strings.Cut(date, "~"); found {
+		date, day = before, "~"+after
+	} else if i := strings.LastIndexByte(date, '-'); i >= 0 {
+		date, day = date[:i], date[i+1:]
+	} else {
+		return "", "", "", fmt.Errorf("malformed date %q", date)
+	}
+	year, month, found := strings.Cut(date, "-")
+	if !found {
+		year, month = "*", year
+	}
+	return year, month, day, nil
+}
+
+// systemdLastDays translates a day counted from the end of the month, with an
+// optional repetition towards the end of the month, into L groups.
+func systemdLastDays(day string) (string, error) {
+	last, rep, found := strings.Cut(day, "/")
+	n, err := strconv.Atoi(last)
+	if err != nil || n < 1 || n > 31 {
+		return "", fmt.Errorf("malformed day ~%s", day)
+	}
+	step := n
+	if found {
+		if step, err = strconv.Atoi(rep); err != nil || step < 1 {
+			return "", fmt.Errorf("malformed day ~%s", day)
+		}
+	}
+	var groups []string
+	for offset := n - 1; offset >= 0; offset -= step {
+		groups = append(groups, "L-"+strconv.Itoa(offset))
+	}
+	return strings.Join(groups, ","), nil
+}
+
+// renderSystemd implements Render for DialectSystemd.
+func (e *Expr) renderSystemd() (string, error) {
+	if e.every != 0 {
+		return "", errors.New("intervals not supported")
+	}
+	weekdays, day, err := e.formatSystemdDays()
+	if err != nil {
+		return "", err
+	}
+
+	year := "*"
+	if !e.y.isEmpty() {
+		year = systemdGroups(e.formatYears(), minYear)
+	}
+	date := year + "-" + systemdGroups(formatField(uint64(e.mon), 1, 12), 1)
+	if strings.HasPrefix(day, "~") {
+		date += day
+	} else {
+		date += "-" + day
+	}
+	clock := systemdGroups(formatField(uint64(e.h), 0, 23), 0) + ":" +
+		systemdGroups(formatField(e.m, 0, 59), 0) + ":" +
+		systemdGroups(formatField(e.s, 0, 59), 0)
+
+	event := date + " " + clock
+	if weekdays != "" {
+		event = weekdays + " " + event
+	}
+	if e.loc != nil {
+		event += " " + e.loc.String()
+	}
+	return event, nil
+}
+
+// formatSystemdDays returns the weekdays, if restricted, and the day of a
+// calendar event matching the days of e.
+func (e *Expr) formatSystemdDays() (weekdays, day string, err error) {
+	domAll, dowAll := e.domAll(), e.dowAll()
+	switch {
+	case e.daysOr && (domAll || dowAll):
+		return "", "*", nil
+	case e.daysOr:
+		return "", "", errors.New("days of month or days of week not supported")
+	case e.domW|e.domLW != 0:
+		return "", "", errors.New("W not supported")
+	}
+
+	// An nth or last day of week is a day of week in a given week of the
+	// month.
+	modifiers := bits.OnesCount8(e.dowL)
+	for _, n := range e.dowN {
+		modifiers += bits.OnesCount8(n)
+	}
+	if modifiers > 0 {
+		if modifiers > 1 || e.dow != 0 || !domAll {
+			return "", "", errors.New("L or # with other days not supported")
+		}
+		if e.dowL != 0 {
+			return formatSystemdWeekdays(e.dowL), "~07/1", nil
+		}
+		for i, n := range e.dowN {
+			if n != 0 {
+				last := 7*i + 7
+				if last > 31 {
+					last = 31
+				}
+				return formatSystemdWeekdays(n), fmt.Sprintf("%02d..%02d", 7*i+1, last), nil
+			}
+		}
+	}
+
+	if !dowAll {
+		weekdays = formatSystemdWeekdays(e.dow)
+	}
+	switch {
+	case e.domL == 0:
+		return weekdays, systemdGroups(formatField(uint64(e.dom), 1, 31), 1), nil
+	case e.dom != 0:
+		return "", "", errors.New("L with other days not supported")
+	case e.domL&(e.domL-1) == 0:
+		return weekdays, fmt.Sprintf("~%02d", bits.Len32(e.domL)), nil
+	}
+
+	// "~n/step" repeats every step days back from the nth last day, down to
+	// the last one, so only evenly spaced days ending at L can be expressed.
+	last := bits.Len32(e.domL) - 1
+	step := last / (bits.OnesCount32(e.domL) - 1)
+	if e.domL&1 == 0 || rangeBits(0, last, step, 0, 30) != uint64(e.domL) {
+		return "", "", errors.New("L with uneven gaps not supported")
+	}
+	return weekdays, fmt.Sprintf("~%02d/%d", last+1, step), nil
+}
+
+// formatSystemdWeekdays returns the names of the days of week of dow, as a list
+// of ranges from Monday to Sunday, e.g., "Mon..Wed,Sat..Sun".
+func formatSystemdWeekdays(dow uint8) string {
+	name := func(wd int) string {
+		return strings.ToUpper(dowNames[wd][:1]) + dowNames[wd][1:3]
+	}
+	var groups []string
+	for i := 0; i < 7; {
+		wd := (i + 1) % 7
+		if dow&(1<<wd) == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < 7 && dow&(1<<((j+2)%7)) != 0 {
+			j++
+		}
+		group := name(wd)
+		if j > i {
+			group += ".." + name((j+1)%7)
+		}
+		groups = append(groups, group)
+		i = j + 1
+	}
+	return strings.Join(groups, ",")
+}
+
+// systemdGroups converts a list of groups from the cron syntax to that of
+// systemd, where ranges use "..", repetitions need a start, and values are
+// padded to two digits, e.g., "*/15" to "00/15", and "1-5" to "01..05".
+func systemdGroups(groups string, min int) string {
+	if groups == "*" {
+		return groups
+	}
+	pad := func(s string) string {
+		if len(s) == 1 {
+			return "0" + s
+		}
+		return s
+	}
+	list := strings.Split(groups, ",")
+	for i, group := range list {
+		r, step, hasStep := strings.Cut(group, "/")
+		if r == "*" {
+			r = strconv.Itoa(min)
+		}
+		from, to, isRange := strings.Cut(r, "-")
+		group = pad(from)
+		if isRange {
+			group += ".." + pad(to)
+		}
+		if hasStep {
+			group += "/" + step
+		}
+		list[i] = group
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,105 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"fmrsn.com/cron"
+)
+
+func TestSystemd(t *testing.T) {
+	systemd := cron.NewParser(cron.WithDialect(cron.DialectSystemd))
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	utc := func(y int, mon time.Month, d, h, m, s int) time.Time {
+		return time.Date(y, mon, d, h, m, s, 0, time.UTC)
+	}
+
+	// Starting on Saturday 2022-01-01.
+	from := utc(2022, 1, 1, 0, 0, 0)
+	tests := []struct {
+		event string
+		next  []time.Time
+	}{{
+		event: "Mon..Fri *-*-* 09:00:00",
+		next:  []time.Time{utc(2022, 1, 3, 9, 0, 0), utc(2022, 1, 4, 9, 0, 0)},
+	}, {
+		event: "*-*-01 03:00",
+		next:  []time.Time{utc(2022, 1, 1, 3, 0, 0), utc(2022, 2, 1, 3, 0, 0)},
+	}, {
+		event: "quarterly",
+		next:  []time.Time{utc(2022, 4, 1, 0, 0, 0), utc(2022, 7, 1, 0, 0, 0)},
+	}, {
+		event: "weekly",
+		next:  []time.Time{utc(2022, 1, 3, 0, 0, 0), utc(2022, 1, 10, 0, 0, 0)},
+	}, {
+		event: "hourly",
+		next:  []time.Time{utc(2022, 1, 1, 1, 0, 0), utc(2022, 1, 1, 2, 0, 0)},
+	}, {
+		event: "minutely",
+		next:  []time.Time{utc(2022, 1, 1, 0, 1, 0), utc(2022, 1, 1, 0, 2, 0)},
+	}, {
+		event: "Sat,Sun 10:30",
+		next:  []time.Time{utc(2022, 1, 1, 10, 30, 0), utc(2022, 1, 2, 10, 30, 0), utc(2022, 1, 8, 10, 30, 0)},
+	}, {
+		event: "Monday",
+		next:  []time.Time{utc(2022, 1, 3, 0, 0, 0), utc(2022, 1, 10, 0, 0, 0)},
+	}, {
+		event: "*:0/15",
+		next:  []time.Time{utc(2022, 1, 1, 0, 15, 0), utc(2022, 1, 1, 0, 30, 0)},
+	}, {
+		event: "*:*:0/20",
+		next:  []time.Time{utc(2022, 1, 1, 0, 0, 20), utc(2022, 1, 1, 0, 0, 40)},
+	}, {
+		event: "2023..2024-03-01 12:00",
+		next:  []time.Time{utc(2023, 3, 1, 12, 0, 0), utc(2024, 3, 1, 12, 0, 0), {}},
+	}, {
+		event: "*-02~01",
+		next:  []time.Time{utc(2022, 2, 28, 0, 0, 0), utc(2023, 2, 28, 0, 0, 0)},
+	}, {
+		event: "Mon *-05~07/1",
+		next:  []time.Time{utc(2022, 5, 30, 0, 0, 0), utc(2023, 5, 29, 0, 0, 0)},
+	}, {
+		event: "06-15 08:00:30",
+		next:  []time.Time{utc(2022, 6, 15, 8, 0, 30), utc(2023, 6, 15, 8, 0, 30)},
+	}, {
+		event: "daily Europe/Berlin",
+		next:  []time.Time{time.Date(2022, 1, 2, 0, 0, 0, 0, berlin), time.Date(2022, 1, 3, 0, 0, 0, 0, berlin)},
+	}}
+	for _, tt := range tests {
+		expr, err := systemd.Parse(tt.event)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		got := from
+		for _, want := range tt.next {
+			if got = expr.Next(got); !got.Equal(want) {
+				t.Errorf("%q: wrong next\ngot:  %v\nwant: %v", tt.event, got, want)
+				break
+			}
+		}
+	}
+
+	for _, event := range []string{
+		"",
+		"Mon..Fri 9",
+		"Mon#2",
+		"MonL",
+		"1..5 09:00",
+		"*-*-* 09:00 extra zone",
+		"*-*-* 25:00",
+		"*-*-32",
+		"*-02~32",
+		"*-*-* 09:00:00.5",
+		"*-*-L",
+		"daily Nowhere/Special",
+		"@daily",
+	} {
+		if _, err := systemd.Parse(event); err == nil {
+			t.Errorf("expected Parse to reject %q", event)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Profile is a set of rules that the schedules of a system must follow,
+// besides those of its syntax. See Validate.
+type Profile int
+
+const (
+	// ProfileKubernetes is the rules of the schedule of a Kubernetes CronJob:
+	// the schedule is in DialectKubernetes, without a time zone prefix, and
+	// the time zone, if any, is an IANA time zone name set apart, as in the
+	// timeZone field of the CronJob.
+	ProfileKubernetes Profile = iota
+
+	// ProfileGitHubActions is the rules of the schedule of a GitHub Actions
+	// workflow, as in on.schedule.cron: the schedule is in DialectPOSIX, runs
+	// in UTC, and activates at most once every 5 minutes.
+	ProfileGitHubActions
+)
+
+// String returns the name of the system of the profile.
+func (p Profile) String() string {
+	switch p {
+	case ProfileKubernetes:
+		return "Kubernetes"
+	case ProfileGitHubActions:
+		return "GitHub Actions"
+	default:
+		return strconv.FormatInt(int64(p), 10)
+	}
+}
+
+// A Rule is a kind of rule of a Profile.
+type Rule int
+
+const (
+	// RuleSyntax requires a schedule to be in the dialect of the profile.
+	RuleSyntax Rule = iota
+
+	// RuleTimeZone restricts the time zone of a schedule, and how it is set.
+	RuleTimeZone
+
+	// RuleInterval sets the shortest interval between activations of a
+	// schedule.
+	RuleInterval
+)
+
+// String returns the name of the rule.
+func (r Rule) String() string {
+	switch r {
+	case RuleSyntax:
+		return "syntax"
+	case RuleTimeZone:
+		return "time zone"
+	case RuleInterval:
+		return "interval"
+	default:
+		return strconv.FormatInt(int64(r), 10)
+	}
+}
+
+// A Violation is a breach of a rule of a Profile.
+type Violation struct {
+	Rule Rule
+	Err  error
+}
+
+func (v Violation) Error() string {
+	return v.Rule.String() + ": " + v.Err.Error()
+}
+
+func (v Violation) Unwrap() error {
+	return v.Err
+}
+
+// A ValidationError is returned by Validate if a schedule breaks any rule of a
+// Profile. Violations lists every rule broken, in the order in which they are
+// listed by Rule.
+type ValidationError struct {
+	Profile    Profile
+	Schedule   string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Error()
+	}
+	return fmt.Sprintf("cron: invalid %v schedule %q: %s", e.Profile, e.Schedule, strings.Join(msgs, "; "))
+}
+
+// Validate parses a schedule of the system of the profile p, in the location
+// named by zone, if not empty, and checks it against the rules of p. If the
+// schedule breaks any of them, the error is a *ValidationError. The result is
+// the parsed schedule, which Next and Prev evaluate as the system would.
+func Validate(p Profile, schedule, zone string) (Expr, error) {
+	var (
+		e          Expr
+		violations []Violation
+		d          Dialect
+	)
+	switch p {
+	case ProfileKubernetes:
+		d = DialectKubernetes
+	case ProfileGitHubActions:
+		d = DialectPOSIX
+	default:
+		return e, fmt.Errorf("cron: validating %q: profile %v not supported", schedule, p)
+	}
+	violate := func(r Rule, err error) {
+		violations = append(violations, Violation{Rule: r, Err: err})
+	}
+
+	// Check the rest of the schedule after a time zone prefix, which neither
+	// system accepts, to report every violation at once.
+	body := schedule
+	for _, prefix := range [...]string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(body, prefix) {
+			_, body, _ = strings.Cut(body, " ")
+			if p == ProfileKubernetes {
+				violate(RuleTimeZone, errors.New("time zone prefix not supported; use the timeZone field instead"))
+			} else {
+				violate(RuleTimeZone, errors.New("time zone prefix not supported; schedules run in UTC"))
+			}
+			break
+		}
+	}
+
+	e, err := NewParser(WithDialect(d)).Parse(body)
+	if err != nil {
+		if inner := errors.Unwrap(err); inner != nil {
+			err = shiftError(inner, len(schedule)-len(body))
+		}
+		violate(RuleSyntax, err)
+	}
+
+	var loc *time.Location
+	switch {
+	case p == ProfileGitHubActions && zone != "" && zone != "UTC" && zone != "Etc/UTC":
+		violate(RuleTimeZone, fmt.Errorf("time zone %q not supported; schedules run in UTC", zone))
+	case p == ProfileGitHubActions:
+		loc = time.UTC
+	case strings.EqualFold(zone, "Local"):
+		violate(RuleTimeZone, errors.New("time zone Local not supported; use an IANA time zone name"))
+	case zone != "":
+		if loc, err = time.LoadLocation(zone); err != nil {
+			violate(RuleTimeZone, err)
+		}
+	}
+
+	if p == ProfileGitHubActions && e.expr != "" {
+		if gap := e.minuteGap(); gap < 5 {
+			violate(RuleInterval, fmt.Errorf("activates %d minute(s) apart, more often than every 5 minutes", gap))
+		}
+	}
+
+	if violations != nil {
+		sort.SliceStable(violations, func(i, j int) bool {
+			return violations[i].Rule < violations[j].Rule
+		})
+		return Expr{}, &ValidationError{Profile: p, Schedule: schedule, Violations: violations}
+	}
+	e.loc = loc
+	return e, nil
+}
+
+// minuteGap returns a lower bound of the minutes between consecutive
+// activations of e, which must have neither a seconds field nor an interval.
+// It assumes that the last hour of a day and the first of the next one may
+// both match, as they do unless e restricts days.
+func (e *Expr) minuteGap() int {
+	gap := 60
+	first := bits.TrailingZeros64(e.m)
+	for m, last := e.m&(e.m-1), first; m != 0; m &= m - 1 {
+		next := bits.TrailingZeros64(m)
+		if next-last < gap {
+			gap = next - last
+		}
+		last = next
+	}
+
+	// The gap between the last minute of an hour and the first of the next
+	// one.
+	const lastHour = 1 << 23
+	if e.h&(e.h>>1) != 0 || e.h&lastHour != 0 && e.h&1 != 0 {
+		if wrap := 60 - (63 - bits.LeadingZeros64(e.m)) + first; wrap < gap {
+			gap = wrap
+		}
+	}
+	return gap
+}
@@ -0,0 +1,98 @@
+package cron_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"fmrsn.com/cron"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		profile  cron.Profile
+		schedule string
+		zone     string
+		want     []cron.Rule
+	}{
+		{cron.ProfileKubernetes, "*/5 * * * *", "", nil},
+		{cron.ProfileKubernetes, "@hourly", "", nil},
+		{cron.ProfileKubernetes, "@every 90s", "", nil},
+		{cron.ProfileKubernetes, "0 9 * * 1-5", "Europe/Berlin", nil},
+		{cron.ProfileKubernetes, "* * * * *", "", nil},
+		{cron.ProfileKubernetes, "0 0 L * *", "", []cron.Rule{cron.RuleSyntax}},
+		{cron.ProfileKubernetes, "0 0 0 * * *", "", []cron.Rule{cron.RuleSyntax}},
+		{cron.ProfileKubernetes, "0 9 * * * 2030", "", []cron.Rule{cron.RuleSyntax}},
+		{cron.ProfileKubernetes, "CRON_TZ=Europe/Berlin 0 9 * * *", "", []cron.Rule{cron.RuleTimeZone}},
+		{cron.ProfileKubernetes, "TZ=Europe/Berlin 0 9 * * *", "Europe/Berlin", []cron.Rule{cron.RuleTimeZone}},
+		{cron.ProfileKubernetes, "0 9 * * *", "Mars/Olympus_Mons", []cron.Rule{cron.RuleTimeZone}},
+		{cron.ProfileKubernetes, "0 9 * * *", "Local", []cron.Rule{cron.RuleTimeZone}},
+		{cron.ProfileKubernetes, "TZ=UTC 0 9 * * 8", "", []cron.Rule{cron.RuleSyntax, cron.RuleTimeZone}},
+		{cron.ProfileKubernetes, "0 0 * * fri-mon", "", []cron.Rule{cron.RuleSyntax}},
+		{cron.ProfileKubernetes, "0 22-2 * * *", "", []cron.Rule{cron.RuleSyntax}},
+		{cron.ProfileKubernetes, "0 0 * JANUARY MONDAY", "", []cron.Rule{cron.RuleSyntax}},
+		{cron.ProfileKubernetes, "0 0 * JAN MON", "", nil},
+		{cron.ProfileKubernetes, "0 0 ? * mon", "", nil},
+		{cron.ProfileKubernetes, "0 0 1 * ?", "", nil},
+
+		{cron.ProfileGitHubActions, "*/5 * * * *", "", nil},
+		{cron.ProfileGitHubActions, "30 5,17 * * mon-fri", "", nil},
+		{cron.ProfileGitHubActions, "0,5,10 * * * *", "UTC", nil},
+		{cron.ProfileGitHubActions, "58 0,12 * * *", "", nil},
+		{cron.ProfileGitHubActions, "* * * * *", "", []cron.Rule{cron.RuleInterval}},
+		{cron.ProfileGitHubActions, "*/4 * * * *", "", []cron.Rule{cron.RuleInterval}},
+		{cron.ProfileGitHubActions, "0,30,33 * * * *", "", []cron.Rule{cron.RuleInterval}},
+		{cron.ProfileGitHubActions, "0,58 9-10 * * *", "", []cron.Rule{cron.RuleInterval}},
+		{cron.ProfileGitHubActions, "0,58 0,23 * * *", "", []cron.Rule{cron.RuleInterval}},
+		{cron.ProfileGitHubActions, "@hourly", "", []cron.Rule{cron.RuleSyntax}},
+		{cron.ProfileGitHubActions, "0 0 * * 7", "", []cron.Rule{cron.RuleSyntax}},
+		{cron.ProfileGitHubActions, "0 9 * * *", "Europe/Berlin", []cron.Rule{cron.RuleTimeZone}},
+		{cron.ProfileGitHubActions, "TZ=Europe/Berlin */2 * * * *", "", []cron.Rule{cron.RuleTimeZone, cron.RuleInterval}},
+	}
+	for _, tt := range tests {
+		_, err := cron.Validate(tt.profile, tt.schedule, tt.zone)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+		var verr *cron.ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%v %q: expected a *ValidationError, got %v", tt.profile, tt.schedule, err)
+			continue
+		}
+		var got []cron.Rule
+		for _, v := range verr.Violations {
+			got = append(got, v.Rule)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v %q: wrong violations\ngot:  %v\nwant: %v", tt.profile, tt.schedule, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	e, err := cron.Validate(cron.ProfileKubernetes, "0 9 * * *", "Europe/Berlin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := e.Next(from), time.Date(2022, 1, 1, 9, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("wrong next\ngot:  %v\nwant: %v", got, want)
+	}
+
+	e, err = cron.Validate(cron.ProfileGitHubActions, "0 9 * * *", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := e.Next(from.In(berlin)), time.Date(2022, 1, 1, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("wrong next\ngot:  %v\nwant: %v", got, want)
+	}
+}